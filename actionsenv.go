@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// actionsEvent is the part of $GITHUB_EVENT_PATH used to find the build run
+// that triggered a workflow_run workflow.
+type actionsEvent struct {
+	WorkflowRun struct {
+		ID         int64  `json:"id"`
+		HeadBranch string `json:"head_branch"`
+		Path       string `json:"path"`
+	} `json:"workflow_run"`
+}
+
+// actionsDefaults derives owner, repo, branch, workflow, run ID and
+// discovery wait settings from the GitHub Actions environment. It returns
+// nothing outside Actions.
+func actionsDefaults(workflowFiles []string) map[string]string {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil
+	}
+
+	// Runs triggered right after the build can see the API lag behind.
+	defaults := map[string]string{"discovery-wait": "2m"}
+	if owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/"); ok {
+		defaults["owner"] = owner
+		defaults["repo"] = repo
+	}
+
+	event := os.Getenv("GITHUB_EVENT_NAME")
+	switch {
+	case event == "workflow_run":
+		// A release workflow triggered by the build: release exactly the
+		// run that triggered it.
+		var ev actionsEvent
+		if data, err := os.ReadFile(os.Getenv("GITHUB_EVENT_PATH")); err == nil && json.Unmarshal(data, &ev) == nil && ev.WorkflowRun.ID != 0 {
+			defaults["run-id"] = strconv.FormatInt(ev.WorkflowRun.ID, 10)
+			defaults["branch"] = ev.WorkflowRun.HeadBranch
+			defaults["workflow"] = path.Base(ev.WorkflowRun.Path)
+		}
+	case os.Getenv("GITHUB_REF_TYPE") == "branch" && !strings.HasPrefix(event, "pull_request"):
+		defaults["branch"] = os.Getenv("GITHUB_REF_NAME")
+		// Running as a later job of the build workflow itself: the
+		// current run is the source.
+		if slices.Contains(workflowFiles, workflowFileOfRef(os.Getenv("GITHUB_WORKFLOW_REF"))) {
+			defaults["run-id"] = os.Getenv("GITHUB_RUN_ID")
+		}
+	}
+	return defaults
+}
+
+// workflowFileOfRef extracts the file name from a GITHUB_WORKFLOW_REF such
+// as "owner/repo/.github/workflows/build.yml@refs/heads/main".
+func workflowFileOfRef(ref string) string {
+	file, _, _ := strings.Cut(ref, "@")
+	if file == "" {
+		return ""
+	}
+	return path.Base(file)
+}
+
+// actionInputs maps the INPUT_* variables the runner sets for the inputs of
+// a `uses:` step onto flags of the same name, e.g. INPUT_TAG-PREFIX.
+func actionInputs(flags *flag.FlagSet) map[string]string {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil
+	}
+
+	inputs := make(map[string]string)
+	flags.VisitAll(func(f *flag.Flag) {
+		if v := strings.TrimSpace(os.Getenv("INPUT_" + strings.ToUpper(f.Name))); v != "" {
+			inputs[f.Name] = v
+		}
+	})
+	return inputs
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// geodePackage is a .geode file found in a build artifact.
+type geodePackage struct {
+	filename string
+	data     []byte
+	mod      *ModJSON
+	// symbols are the debug symbols collected for the package by -symbols.
+	symbols []extraAsset
+	// built is the sha256 of the package as it came out of the build,
+	// before -symbols or -stamp rewrote data.
+	built string
+}
+
+// localPackages loads a locally built .geode file in place of a build
+// artifact.
+func localPackages(path string) ([]*geodePackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .geode file: %w", err)
+	}
+	pkg, err := newGeodePackage(filepath.Base(path), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	slog.Info("Loaded .geode file", "file", path, "mod_id", pkg.mod.ID)
+	return []*geodePackage{pkg}, nil
+}
+
+// Package layouts accepted by -package-layout.
+const (
+	layoutAuto     = "auto"
+	layoutTop      = "top"
+	layoutNested   = "nested"
+	layoutArtifact = "artifact"
+)
+
+var packageLayouts = []string{layoutAuto, layoutTop, layoutNested, layoutArtifact}
+
+// extractGeodePackages returns the .geode packages in the artifact zip
+// together with their parsed mod.json, according to layout:
+//
+//   - top: .geode files at the root of the artifact
+//   - nested: .geode files inside zip archives in the artifact
+//   - artifact: the artifact itself is the content of a single .geode
+//   - auto: .geode files anywhere in the artifact, including inside .tar.gz
+//     and .tar.zst tarballs, falling back to nested and then artifact
+//
+// Artifacts from monorepos may carry several mods, but each mod ID may only
+// appear once unless merge is set, in which case per-platform packages of
+// the same mod are merged into one.
+func extractGeodePackages(zipData []byte, layout string, merge bool) ([]*geodePackage, error) {
+	r, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip reader: %w", err)
+	}
+
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		slog.Debug("Listing contents of artifact zip")
+		debugListZipContents(r)
+	}
+
+	c := &packageCollector{seen: make(map[string]string), merge: merge}
+	switch layout {
+	case layoutTop:
+		err = c.scanEntries(r, true)
+	case layoutNested:
+		err = c.scanNestedZips(r)
+	case layoutArtifact:
+		err = c.add("artifact.zip", zipData)
+	default:
+		err = c.scanEntries(r, false)
+		if err == nil && len(c.pkgs) == 0 {
+			slog.Debug("No .geode files in artifact, looking in nested zips")
+			err = c.scanNestedZips(r)
+		}
+		if err == nil && len(c.pkgs) == 0 && hasRootModJSON(r) {
+			slog.Debug("Artifact has a root mod.json, treating it as the package")
+			err = c.add("artifact.zip", zipData)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.pkgs) == 0 {
+		return nil, fmt.Errorf(".geode file not found in artifact (package layout %s)", layout)
+	}
+	return c.pkgs, nil
+}
+
+// packageCollector gathers packages while rejecting, or with merge set
+// merging, duplicate mod IDs.
+type packageCollector struct {
+	pkgs  []*geodePackage
+	seen  map[string]string
+	merge bool
+}
+
+func (c *packageCollector) add(path string, data []byte) error {
+	pkg, err := newGeodePackage(filepath.Base(path), data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if prev, ok := c.seen[pkg.mod.ID]; ok {
+		if !c.merge {
+			return fmt.Errorf("mod %s is packaged twice, in %s and %s", pkg.mod.ID, prev, path)
+		}
+		i := slices.IndexFunc(c.pkgs, func(p *geodePackage) bool { return p.mod.ID == pkg.mod.ID })
+		merged, err := mergePackages(c.pkgs[i], pkg)
+		if err != nil {
+			return fmt.Errorf("failed to merge %s into %s: %w", path, prev, err)
+		}
+		slog.Info("Merged .geode file", "file", path, "into", prev, "mod_id", pkg.mod.ID)
+		c.pkgs[i] = merged
+		return nil
+	}
+	c.seen[pkg.mod.ID] = path
+	if !strings.HasSuffix(pkg.filename, ".geode") {
+		pkg.filename = pkg.mod.ID + ".geode"
+	}
+
+	slog.Info("Found .geode file", "file", pkg.filename, "mod_id", pkg.mod.ID)
+	c.pkgs = append(c.pkgs, pkg)
+	return nil
+}
+
+// scanEntries adds the .geode files in r, only those at the root if topOnly
+// is set and otherwise also those in subdirectories and tarballs.
+func (c *packageCollector) scanEntries(r *zip.Reader, topOnly bool) error {
+	for _, f := range r.File {
+		if topOnly && strings.Contains(f.Name, "/") {
+			continue
+		}
+		isGeode := strings.HasSuffix(f.Name, ".geode")
+		if !isGeode && (topOnly || tarballCompression(f.Name) == "") {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s inside zip: %w", f.Name, err)
+		}
+
+		if isGeode {
+			slog.Debug("Extracted .geode file from zip", "path", f.Name, "bytes", len(data))
+			if err := c.add(f.Name, data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		slog.Debug("Searching tarball for .geode files", "path", f.Name, "bytes", len(data))
+		err = readTarball(f.Name, data, func(name string, data []byte) error {
+			if !strings.HasSuffix(name, ".geode") {
+				return nil
+			}
+			slog.Debug("Extracted .geode file from tarball", "tarball", f.Name, "path", name, "bytes", len(data))
+			return c.add(f.Name+"/"+name, data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanNestedZips adds the .geode files inside the zip archives in r.
+func (c *packageCollector) scanNestedZips(r *zip.Reader) error {
+	for _, f := range r.File {
+		if !strings.HasSuffix(strings.ToLower(f.Name), ".zip") {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s inside zip: %w", f.Name, err)
+		}
+		inner, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return fmt.Errorf("failed to open nested zip %s: %w", f.Name, err)
+		}
+		for _, g := range inner.File {
+			if !strings.HasSuffix(g.Name, ".geode") {
+				continue
+			}
+			pkgData, err := readZipFile(g)
+			if err != nil {
+				return fmt.Errorf("failed to read %s inside %s: %w", g.Name, f.Name, err)
+			}
+			slog.Debug("Extracted .geode file from nested zip", "zip", f.Name, "path", g.Name, "bytes", len(pkgData))
+			if err := c.add(f.Name+"/"+g.Name, pkgData); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func hasRootModJSON(r *zip.Reader) bool {
+	for _, f := range r.File {
+		if f.Name == "mod.json" {
+			return true
+		}
+	}
+	return false
+}
+
+func newGeodePackage(filename string, data []byte) (*geodePackage, error) {
+	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		if r, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+			slog.Debug("Listing contents of .geode zip", "file", filename)
+			debugListZipContents(r)
+		}
+	}
+
+	mod, err := readModJSON(data)
+	if err != nil {
+		if !modJSONUnreadable(err) {
+			return nil, err
+		}
+		fallback, ok := modFromFilename(filename)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse mod.json: %w", err)
+		}
+		slog.Warn("Failed to parse mod.json, using the version from the file name", "file", filename, "version", fallback.Version, "error", err)
+		mod = fallback
+	}
+	return &geodePackage{filename: filename, data: data, mod: mod, built: sha256Hex(data)}, nil
+}
+
+// modJSONUnreadable reports whether err from readModJSON means the package
+// has no mod.json or one that is not valid JSON, the cases the
+// -filename-version-regex fallback is for. A mod.json that decodes but fails
+// validation is not.
+func modJSONUnreadable(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, errModJSONNotFound) || errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+// filenameVersionPattern is the -filename-version-regex fallback, nil if
+// unset.
+var filenameVersionPattern *regexp.Regexp
+
+// modFromFilename builds a minimal mod.json from a package file name using
+// filenameVersionPattern. The version is its "version" group, or its first
+// group, and the mod ID its "id" group, or else the file name without its
+// extension and the version. The ID must be a valid mod ID.
+func modFromFilename(filename string) (*ModJSON, bool) {
+	re := filenameVersionPattern
+	if re == nil {
+		return nil, false
+	}
+	m := re.FindStringSubmatchIndex(filename)
+	if m == nil || len(m) < 4 {
+		return nil, false
+	}
+
+	vi := 1
+	if i := re.SubexpIndex("version"); i > 0 {
+		vi = i
+	}
+	start, end := m[2*vi], m[2*vi+1]
+	if start < 0 || start == end {
+		return nil, false
+	}
+	version := filename[start:end]
+
+	var id string
+	if i := re.SubexpIndex("id"); i > 0 && m[2*i] >= 0 {
+		id = filename[m[2*i]:m[2*i+1]]
+	} else {
+		// Drop the version, with the "v" and separator before it.
+		prefix := filename[:start]
+		if p, ok := strings.CutSuffix(prefix, "v"); ok && (p == "" || strings.ContainsAny(p[len(p)-1:], "-_.")) {
+			prefix = p
+		}
+		prefix = strings.TrimRight(prefix, "-_.")
+		suffix := strings.TrimSuffix(filename[end:], filepath.Ext(filename))
+		if prefix == "" {
+			suffix = strings.TrimLeft(suffix, "-_.")
+		}
+		id = prefix + suffix
+	}
+	if !modIDPattern.MatchString(id) {
+		slog.Warn("File name does not give a valid mod ID", "file", filename, "id", id)
+		return nil, false
+	}
+	return &ModJSON{ID: id, Name: id, Version: version}, true
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func debugListZipContents(r *zip.Reader) {
+	for _, f := range r.File {
+		slog.Debug("Zip entry", "path", f.Name)
+	}
+}
+
+// readPackageFile returns the contents of the root-level file name inside
+// pkg, matched case-insensitively, or nil if there is none.
+func readPackageFile(pkg *geodePackage, name string) ([]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(pkg.data), int64(len(pkg.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+	for _, f := range r.File {
+		if strings.EqualFold(f.Name, name) {
+			return readZipFile(f)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+)
+
+func TestModFromFilename(t *testing.T) {
+	defer func(re *regexp.Regexp) { filenameVersionPattern = re }(filenameVersionPattern)
+
+	tests := []struct {
+		name     string
+		pattern  string
+		filename string
+		wantID   string
+		wantVer  string
+		wantOK   bool
+	}{
+		{"no pattern", "", "mod-1.0.0.geode", "", "", false},
+		{"first group", `-(\d+\.\d+\.\d+)\.geode$`, "my.mod-1.2.3.geode", "my.mod", "1.2.3", true},
+		{"first group with v", `v(\d+\.\d+\.\d+)`, "my.mod_v1.2.3.geode", "my.mod", "1.2.3", true},
+		{"version first", `^(\d+\.\d+\.\d+)`, "1.2.3-my.mod.geode", "my.mod", "1.2.3", true},
+		{"v ending the ID", `(\d+\.\d+\.\d+)`, "my.dev1.2.3.geode", "my.dev", "1.2.3", true},
+		{"named groups", `^(?P<id>[^-]+)-v(?P<version>[\d.]+)\.geode$`, "my.mod-v1.2.3.geode", "my.mod", "1.2.3", true},
+		{"named version only", `(?P<prefix>my)\.mod-(?P<version>\d+\.\d+\.\d+)`, "my.mod-2.0.0.geode", "my.mod", "2.0.0", true},
+		{"invalid ID", `-(\d+\.\d+\.\d+)\.geode$`, "build-2.0.0.geode", "", "", false},
+		{"invalid id group", `^(?P<id>[^-]+)-(?P<version>[\d.]+)\.geode$`, "My Mod-1.0.0.geode", "", "", false},
+		{"no match", `-(\d+\.\d+\.\d+)\.geode$`, "my.mod.geode", "", "", false},
+		{"no groups", `\.geode$`, "my.mod.geode", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filenameVersionPattern = nil
+			if tt.pattern != "" {
+				filenameVersionPattern = regexp.MustCompile(tt.pattern)
+			}
+			mod, ok := modFromFilename(tt.filename)
+			if ok != tt.wantOK {
+				t.Fatalf("modFromFilename(%q) ok = %v, want %v", tt.filename, ok, tt.wantOK)
+			}
+			if ok && (mod.ID != tt.wantID || mod.Version != tt.wantVer) {
+				t.Errorf("modFromFilename(%q) = id %q version %q, want id %q version %q", tt.filename, mod.ID, mod.Version, tt.wantID, tt.wantVer)
+			}
+		})
+	}
+}
+
+func TestNewGeodePackageFallback(t *testing.T) {
+	defer func(re *regexp.Regexp) { filenameVersionPattern = re }(filenameVersionPattern)
+	filenameVersionPattern = regexp.MustCompile(`-(\d+\.\d+\.\d+)\.geode$`)
+
+	tests := []struct {
+		name         string
+		files        []string
+		wantFallback bool
+		wantErr      bool
+	}{
+		{"no mod.json", []string{"logo.png", "png"}, true, false},
+		{"invalid JSON", []string{"mod.json", `{"id": "my.mod",`}, true, false},
+		{"wrong type", []string{"mod.json", `{"id": 5}`}, true, false},
+		{"fails validation", []string{"mod.json", `{"id": "my.mod", "version": "1.0.0"}`}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := newGeodePackage("my.mod-1.2.3.geode", testZip(t, tt.files...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newGeodePackage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var validation *modJSONError
+				if !errors.As(err, &validation) {
+					t.Errorf("newGeodePackage() error = %v, want the validation error", err)
+				}
+				return
+			}
+			if tt.wantFallback && (pkg.mod.ID != "my.mod" || pkg.mod.Version != "1.2.3") {
+				t.Errorf("newGeodePackage() mod = %+v, want the file name fallback", pkg.mod)
+			}
+		})
+	}
+}
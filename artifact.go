@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// downloadRunArtifact finds the build artifact of run, downloads it and
+// verifies it against the API metadata, returning the artifact zip.
+func (r *releaser) downloadRunArtifact(ctx context.Context, run *github.WorkflowRun) ([]byte, error) {
+	artifact, err := retryDiscovery(ctx, r.opts.discoveryWait, func() (*github.Artifact, error) {
+		return r.findRunArtifact(ctx, run)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := getArtifactMetadata(ctx, r.client, r.artifactOwner, r.artifactRepo, artifact.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifact metadata: %w", err)
+	}
+
+	cachePath := r.artifactCachePath(run.GetID(), artifact.GetID())
+	if cachePath != "" {
+		if data, err := os.ReadFile(cachePath); err == nil {
+			if err := verifyArtifact(data, meta); err == nil {
+				slog.Info("Using cached artifact", "path", cachePath, "bytes", len(data))
+				return data, nil
+			}
+			slog.Warn("Cached artifact does not match, downloading it again", "path", cachePath, "error", err)
+		}
+	}
+
+	tmpDir := ""
+	if cachePath != "" {
+		tmpDir = filepath.Dir(cachePath)
+		if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create artifact cache directory: %w", err)
+		}
+	}
+	tmpZipFile, err := os.CreateTemp(tmpDir, "artifact-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file for artifact download: %w", err)
+	}
+	defer func() {
+		tmpZipFile.Close()
+		os.Remove(tmpZipFile.Name())
+	}()
+
+	slog.Debug("Downloading artifact", "artifact_id", artifact.GetID(), "path", tmpZipFile.Name())
+
+	start := time.Now()
+	resolve := artifactURLResolver(r.client, r.artifactOwner, r.artifactRepo, artifact.GetID())
+	written, err := downloadWithResume(ctx, r.http, resolve, tmpZipFile, r.opts.downloadRetries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download artifact: %w", err)
+	}
+	slog.Info("Downloaded artifact", "bytes", written, "duration", time.Since(start))
+
+	zipData, err := os.ReadFile(tmpZipFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downloaded artifact zip from temp file: %w", err)
+	}
+	if err := verifyArtifact(zipData, meta); err != nil {
+		return nil, fmt.Errorf("failed to verify artifact download: %w", err)
+	}
+
+	if cachePath != "" {
+		tmpZipFile.Close()
+		if err := os.Rename(tmpZipFile.Name(), cachePath); err != nil {
+			slog.Warn("Failed to cache artifact", "path", cachePath, "error", err)
+		} else {
+			slog.Debug("Cached artifact", "path", cachePath)
+		}
+	}
+	return zipData, nil
+}
+
+// findRunArtifact returns the artifact of run named by -artifact.
+func (r *releaser) findRunArtifact(ctx context.Context, run *github.WorkflowRun) (*github.Artifact, error) {
+	slog.Debug("Listing artifacts", "run_id", run.GetID())
+	arts, _, err := r.client.Actions.ListWorkflowRunArtifacts(ctx, r.artifactOwner, r.artifactRepo, run.GetID(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	slog.Debug("Found artifacts", "count", len(arts.Artifacts))
+
+	var artifact *github.Artifact
+	for _, a := range arts.Artifacts {
+		slog.Debug("Artifact", "artifact_id", a.GetID(), "name", a.GetName())
+		if a.GetName() == r.opts.artifactName {
+			artifact = a
+			break
+		}
+	}
+	if artifact == nil {
+		return nil, withExitCode(exitArtifactNotFound, fmt.Errorf("artifact '%s' not found for run %d", r.opts.artifactName, run.GetID()))
+	}
+	slog.Debug("Selected artifact", "artifact_id", artifact.GetID())
+	return artifact, nil
+}
+
+// artifactCachePath returns where the artifact is cached, under
+// <cache dir>/<run id>/<artifact id>.zip, or "" if caching is disabled.
+func (r *releaser) artifactCachePath(runID, artifactID int64) string {
+	if r.opts.noCache {
+		return ""
+	}
+	dir := r.opts.cacheDir
+	if dir == "" {
+		userCache, err := os.UserCacheDir()
+		if err != nil {
+			slog.Debug("No user cache directory, not caching artifacts", "error", err)
+			return ""
+		}
+		dir = filepath.Join(userCache, "gwtreleaser")
+	}
+	return filepath.Join(dir, strconv.FormatInt(runID, 10), strconv.FormatInt(artifactID, 10)+".zip")
+}
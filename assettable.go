@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// assetTableSection renders a markdown table of the assets uploaded with pr
+// so users can check a download's size and digest. The platforms column is
+// filled in for .geode assets only; other assets show a dash.
+func assetTableSection(pr *pendingRelease) (string, error) {
+	if len(pr.assets) == 0 {
+		return "", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("### Assets\n\n| Asset | Size | SHA-256 | Platforms |\n| --- | --- | --- | --- |")
+	for i, a := range pr.assets {
+		target := "—"
+		// The package itself may be uploaded under a name without the
+		// .geode extension.
+		if i == 0 || strings.HasSuffix(a.name, ".geode") {
+			platforms, err := packagePlatforms(&geodePackage{filename: a.name, data: a.data, mod: pr.pkg.mod})
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case len(platforms) == len(platformOrder):
+				target = "universal"
+			case len(platforms) > 0:
+				target = strings.Join(platforms, ", ")
+			}
+		}
+		fmt.Fprintf(&b, "\n| `%s` | %s | `%s` | %s |", a.name, formatBytes(int64(len(a.data))), sha256Hex(a.data), target)
+	}
+	return b.String(), nil
+}
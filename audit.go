@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// auditRecord is the JSON audit trail written for each run with -audit-dir.
+type auditRecord struct {
+	Actor      string     `json:"actor"`
+	Repository string     `json:"repository"`
+	Started    time.Time  `json:"started"`
+	Finished   time.Time  `json:"finished"`
+	RunID      int64      `json:"run_id,omitempty"`
+	Result     *runResult `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Operations []auditOp  `json:"operations"`
+}
+
+// auditOp is one mutating GitHub API request.
+type auditOp struct {
+	Time      time.Time `json:"time"`
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	Status    int       `json:"status,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// auditLog collects the operations of the current run.
+type auditLog struct {
+	mu      sync.Mutex
+	started time.Time
+	ops     []auditOp
+}
+
+func (l *auditLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.started = time.Now()
+	l.ops = nil
+}
+
+// auditTransport records every request to the GitHub API that is not a
+// read.
+type auditTransport struct {
+	base http.RoundTripper
+	log  *auditLog
+}
+
+func (t auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+
+	op := auditOp{Time: time.Now(), Method: req.Method, URL: req.URL.Redacted()}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		op.Error = err.Error()
+	} else {
+		op.Status = resp.StatusCode
+		op.RequestID = resp.Header.Get("X-GitHub-Request-Id")
+	}
+
+	t.log.mu.Lock()
+	t.log.ops = append(t.log.ops, op)
+	t.log.mu.Unlock()
+	return resp, err
+}
+
+// writeAudit writes the audit record of the run that just finished into
+// -audit-dir, logging rather than returning failures.
+func (r *releaser) writeAudit(ctx context.Context, runID int64, res *runResult, runErr error) {
+	r.audit.mu.Lock()
+	rec := &auditRecord{
+		Actor:      r.auditActor(ctx),
+		Repository: r.owner + "/" + r.repo,
+		Started:    r.audit.started,
+		Finished:   time.Now(),
+		RunID:      runID,
+		Result:     res,
+		Operations: append([]auditOp{}, r.audit.ops...),
+	}
+	r.audit.mu.Unlock()
+	if runErr != nil {
+		rec.Error = runErr.Error()
+	}
+
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(r.opts.auditDir, 0o755)
+	}
+	path := filepath.Join(r.opts.auditDir, fmt.Sprintf("%s-%d.json", rec.Started.UTC().Format("20060102T150405Z"), runID))
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0o644)
+	}
+	if err != nil {
+		slog.Error("Failed to write audit record", "path", path, "error", err)
+		return
+	}
+	slog.Debug("Wrote audit record", "path", path, "operations", len(rec.Operations))
+}
+
+// auditActor names whoever the token acts for. Actions tokens cannot read
+// their own user, so the workflow's actor stands in for them.
+func (r *releaser) auditActor(ctx context.Context) string {
+	if r.actor != "" {
+		return r.actor
+	}
+	if user, _, err := r.client.Users.Get(context.WithoutCancel(ctx), ""); err == nil {
+		r.actor = user.GetLogin()
+	} else if actor := os.Getenv("GITHUB_ACTOR"); actor != "" {
+		r.actor = actor + " (GitHub Actions)"
+	}
+	return r.actor
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// batchResult is the outcome of one manifest entry.
+type batchResult struct {
+	Repository string     `json:"repository"`
+	Result     *runResult `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	// Pending lists the tags a dry run would have released.
+	Pending []string `json:"pending,omitempty"`
+}
+
+// batchMain implements "batch": it releases every repository listed in a
+// manifest and prints a combined summary. The manifest is a JSON array of
+// objects with the same keys as the config file; each needs at least owner
+// and repo. Flags given before the manifest path apply to every entry, and
+// an entry's own settings take precedence over them.
+func batchMain(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[len(args)-1], "-") {
+		fmt.Fprintln(os.Stderr, "Usage: batch [flags] manifest.json")
+		return exitUsage
+	}
+	shared, manifestPath := args[:len(args)-1], args[len(args)-1]
+
+	entries, err := loadManifest(manifestPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(0)
+	defer stop()
+
+	var results []batchResult
+	format := "text"
+	code := 0
+	for i, entry := range entries {
+		opts := options{multiRepo: true}
+		fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+		opts.register(fs)
+		if err := opts.parse(fs, append(slices.Clone(shared), entry...)); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: entry %d: %v\n", manifestPath, i+1, err)
+			return exitUsage
+		}
+		format = opts.outputFormat
+
+		res, err := runWithTimeout(ctx, &opts)
+		br := batchResult{Repository: opts.owner + "/" + opts.repo, Result: res}
+		if err != nil {
+			slog.Error("Release failed", "repository", br.Repository, "error", err)
+			br.Error = err.Error()
+			code = max(code, exitCodeOf(err))
+		}
+		results = append(results, br)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err := writeBatchResults(os.Stdout, format, results); err != nil {
+		slog.Error("Failed to write result", "error", err)
+		return exitFailure
+	}
+	return code
+}
+
+func runWithTimeout(ctx context.Context, opts *options) (*runResult, error) {
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+	return run(ctx, opts)
+}
+
+// loadManifest reads the manifest at path and turns each entry into flag
+// arguments.
+func loadManifest(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var raw []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("manifest %s lists no repositories", path)
+	}
+
+	entries := make([][]string, len(raw))
+	for i, cfg := range raw {
+		if cfg["owner"] == nil || cfg["repo"] == nil {
+			return nil, fmt.Errorf("%s: entry %d needs owner and repo", path, i+1)
+		}
+		for _, name := range slices.Sorted(maps.Keys(cfg)) {
+			values, err := configValues(cfg[name])
+			if err != nil {
+				return nil, fmt.Errorf("%s: entry %d: %s: %w", path, i+1, name, err)
+			}
+			for _, v := range values {
+				entries[i] = append(entries[i], "-"+name+"="+v)
+			}
+		}
+	}
+	return entries, nil
+}
+
+func writeBatchResults(w io.Writer, format string, results []batchResult) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	for _, br := range results {
+		var err error
+		switch {
+		case br.Error != "":
+			_, err = fmt.Fprintf(w, "%s: failed: %s\n", br.Repository, br.Error)
+		case len(br.Pending) > 0:
+			_, err = fmt.Fprintf(w, "%s: would release %s\n", br.Repository, strings.Join(br.Pending, ", "))
+		case br.Result == nil || len(br.Result.Releases) == 0:
+			_, err = fmt.Fprintf(w, "%s: nothing to release\n", br.Repository)
+		default:
+			for _, rel := range br.Result.Releases {
+				if _, err = fmt.Fprintf(w, "%s: released %s: %s\n", br.Repository, rel.Tag, rel.ReleaseURL); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
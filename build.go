@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// runBuild runs the local build command through the shell and returns the
+// path of the .geode file it produced: the newest one under dir written
+// after the build started.
+func runBuild(ctx context.Context, command, dir string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	slog.Info("Running build command", "cmd", command)
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	// The build's own output goes to stderr so stdout stays reserved for
+	// the run result.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("build command failed: %w", err)
+	}
+	slog.Info("Build finished", "duration", time.Since(start))
+
+	var newest string
+	var newestTime time.Time
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".geode") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if mt := info.ModTime(); !mt.Before(start) && mt.After(newestTime) {
+			newest, newestTime = path, mt
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search for the built package: %w", err)
+	}
+	if newest == "" {
+		return "", errors.New("build command did not produce a .geode file")
+	}
+	slog.Debug("Found built package", "path", newest)
+	return newest, nil
+}
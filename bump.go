@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/mod/semver"
+)
+
+// bumpVersion increments the major, minor or patch component of a normalized
+// version. A prerelease is promoted to its release for a patch bump.
+func bumpVersion(version, kind string) (string, error) {
+	core := strings.TrimPrefix(semver.Canonical("v"+version), "v")
+	core, pre, _ := strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("cannot bump invalid version %q", version)
+	}
+
+	var n [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return "", fmt.Errorf("cannot bump invalid version %q", version)
+		}
+		n[i] = v
+	}
+
+	switch kind {
+	case "major":
+		n = [3]int{n[0] + 1, 0, 0}
+	case "minor":
+		n = [3]int{n[0], n[1] + 1, 0}
+	case "patch":
+		if pre == "" {
+			n[2]++
+		}
+	default:
+		return "", fmt.Errorf("unknown bump kind %q (want major, minor, patch or auto)", kind)
+	}
+	return fmt.Sprintf("%d.%d.%d", n[0], n[1], n[2]), nil
+}
+
+var breakingSubject = regexp.MustCompile(`^[a-zA-Z]+(\([^)]*\))?!:`)
+
+// bumpKindFromCommits derives the bump from conventional commit messages:
+// breaking changes bump major, features bump minor, anything else patch.
+func bumpKindFromCommits(messages []string) string {
+	kind := "patch"
+	for _, msg := range messages {
+		subject, _, _ := strings.Cut(msg, "\n")
+		switch {
+		case breakingSubject.MatchString(subject) || strings.Contains(msg, "BREAKING CHANGE"):
+			return "major"
+		case strings.HasPrefix(subject, "feat:") || strings.HasPrefix(subject, "feat("):
+			kind = "minor"
+		}
+	}
+	return kind
+}
+
+// commitMessagesSinceLatestRelease lists the messages of the commits on
+// branch after the latest release's tag. It returns nil if nothing has been
+// released yet.
+func commitMessagesSinceLatestRelease(ctx context.Context, client *github.Client, owner, repo, branch string) ([]string, error) {
+	latest, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo)
+	if isStatus(err, http.StatusNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+
+	cmp, _, err := client.Repositories.CompareCommits(ctx, owner, repo, latest.GetTagName(), branch, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s: %w", latest.GetTagName(), branch, err)
+	}
+
+	messages := make([]string, 0, len(cmp.Commits))
+	for _, c := range cmp.Commits {
+		messages = append(messages, c.GetCommit().GetMessage())
+	}
+	return messages, nil
+}
+
+// setModJSONVersion rewrites the top-level version of a mod.json in place,
+// leaving the rest of the file's formatting untouched. Dependencies can
+// carry the same version string, so each occurrence is tried until the one
+// that is the top-level version is found.
+func setModJSONVersion(raw []byte, oldVersion, newVersion string) ([]byte, error) {
+	re := regexp.MustCompile(`("version"\s*:\s*")` + regexp.QuoteMeta(oldVersion) + `"`)
+	matches := re.FindAllSubmatchIndex(raw, -1)
+	if matches == nil {
+		return nil, errors.New("version field not found in mod.json")
+	}
+
+	for _, loc := range matches {
+		out := make([]byte, 0, len(raw)+len(newVersion))
+		out = append(out, raw[:loc[3]]...)
+		out = append(out, newVersion...)
+		out = append(out, '"')
+		out = append(out, raw[loc[1]:]...)
+
+		var check ModJSON
+		if err := json.Unmarshal(out, &check); err == nil && check.Version == newVersion {
+			return out, nil
+		}
+	}
+	return nil, errors.New("failed to rewrite the top-level version in mod.json")
+}
+
+// bumpAndWait computes the next version, commits it to mod.json on the
+// branch and waits for the workflow run building that commit to succeed.
+//
+// Pushes made with the Actions GITHUB_TOKEN do not trigger workflows, so this
+// mode needs a personal access token or app token.
+func bumpAndWait(ctx context.Context, client *github.Client, owner, repo string, opts *options) (*github.WorkflowRun, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, owner, repo, opts.repoModJSON, &github.RepositoryContentGetOptions{Ref: opts.branch})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s from %s: %w", opts.repoModJSON, opts.branch, err)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%s is not a file", opts.repoModJSON)
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", opts.repoModJSON, err)
+	}
+
+	mod, err := parseModJSON([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	current, err := normalizeVersion(mod.Version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version in %s: %w", opts.repoModJSON, err)
+	}
+
+	kind := opts.bump
+	if kind == "auto" {
+		messages, err := commitMessagesSinceLatestRelease(ctx, client, owner, repo, opts.branch)
+		if err != nil {
+			return nil, err
+		}
+		kind = bumpKindFromCommits(messages)
+		slog.Info("Derived version bump from commits", "bump", kind, "commits", len(messages))
+	}
+
+	next, err := bumpVersion(current, kind)
+	if err != nil {
+		return nil, err
+	}
+	// Keep whatever "v" style the mod.json already uses.
+	nextRaw := next
+	if strings.HasPrefix(mod.Version, "v") {
+		nextRaw = "v" + next
+	}
+
+	updated, err := setModJSONVersion([]byte(content), mod.Version, nextRaw)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Bumping version", "from", mod.Version, "to", nextRaw, "path", opts.repoModJSON)
+	res, _, err := client.Repositories.UpdateFile(ctx, owner, repo, opts.repoModJSON, &github.RepositoryContentFileOptions{
+		Message: github.String("Bump version to " + nextRaw),
+		Content: updated,
+		SHA:     file.SHA,
+		Branch:  github.String(opts.branch),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit version bump: %w", err)
+	}
+	commitSHA := res.Commit.GetSHA()
+	slog.Info("Pushed version bump", "sha", commitSHA, "branch", opts.branch)
+
+	return waitForRun(ctx, client, owner, repo, opts.workflowFiles, opts.branch, commitSHA, opts.pollInterval)
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version, kind string
+		want          string
+		wantErr       bool
+	}{
+		{"1.2.3", "major", "2.0.0", false},
+		{"1.2.3", "minor", "1.3.0", false},
+		{"1.2.3", "patch", "1.2.4", false},
+		{"1.2.3-beta.1", "patch", "1.2.3", false},
+		{"1.2.3-beta.1", "minor", "1.3.0", false},
+		{"1.2.3", "huge", "", true},
+	}
+	for _, tt := range tests {
+		got, err := bumpVersion(tt.version, tt.kind)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("bumpVersion(%q, %q) error = %v, wantErr %v", tt.version, tt.kind, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("bumpVersion(%q, %q) = %q, want %q", tt.version, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestBumpKindFromCommits(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []string
+		want     string
+	}{
+		{"none", nil, "patch"},
+		{"fixes", []string{"fix: crash", "chore: tidy"}, "patch"},
+		{"feature", []string{"fix: crash", "feat: new option"}, "minor"},
+		{"scoped feature", []string{"feat(ui): new button"}, "minor"},
+		{"breaking subject", []string{"feat: new option", "refactor!: drop old API"}, "major"},
+		{"breaking footer", []string{"fix: crash\n\nBREAKING CHANGE: settings renamed"}, "major"},
+		{"feature in body only", []string{"fix: crash\n\nfeat: mentioned"}, "patch"},
+	}
+	for _, tt := range tests {
+		if got := bumpKindFromCommits(tt.messages); got != tt.want {
+			t.Errorf("%s: bumpKindFromCommits() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetModJSONVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "keeps formatting",
+			raw:  "{\n    \"id\": \"a.b\",\n    \"version\" : \"1.0.0\",\n    \"name\": \"Mod\"\n}\n",
+			want: "{\n    \"id\": \"a.b\",\n    \"version\" : \"1.1.0\",\n    \"name\": \"Mod\"\n}\n",
+		},
+		{
+			name: "only the top-level version",
+			raw:  `{"dependencies": [{"id": "x", "version": "1.0.0"}], "version": "1.0.0"}`,
+			want: `{"dependencies": [{"id": "x", "version": "1.0.0"}], "version": "1.1.0"}`,
+		},
+		{
+			name:    "missing version",
+			raw:     `{"id": "a.b"}`,
+			wantErr: true,
+		},
+		{
+			name:    "different version",
+			raw:     `{"version": "2.0.0"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := setModJSONVersion([]byte(tt.raw), "1.0.0", "1.1.0")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setModJSONVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(got) != tt.want {
+				t.Errorf("setModJSONVersion() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// parseChannels parses -channel values of the form "branch=channel".
+func parseChannels(values []string) (map[string]string, error) {
+	channels := make(map[string]string, len(values))
+	for _, v := range values {
+		branch, channel, ok := strings.Cut(v, "=")
+		branch, channel = strings.TrimSpace(branch), strings.TrimSpace(channel)
+		if !ok || branch == "" || channel == "" {
+			return nil, fmt.Errorf("invalid -channel %q (want branch=channel)", v)
+		}
+		if !semver.IsValid("v0.0.0-" + channel) {
+			return nil, fmt.Errorf("invalid -channel %q: %q cannot be used in a version", v, channel)
+		}
+		channels[branch] = channel
+	}
+	return channels, nil
+}
+
+// channelVersion returns the version to release on channel: the mod's
+// version with a "-<channel>.<run number>" suffix, unless the mod.json
+// version already is a prerelease.
+func channelVersion(version, channel string, runNumber int) (string, error) {
+	if semver.Prerelease("v"+version) != "" {
+		return version, nil
+	}
+	if runNumber == 0 {
+		return "", fmt.Errorf("releasing to the %s channel needs a workflow run number", channel)
+	}
+	return fmt.Sprintf("%s-%s.%d", version, channel, runNumber), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseChannels(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"none", nil, map[string]string{}, false},
+		{"several", []string{"beta=beta", " dev = nightly "}, map[string]string{"beta": "beta", "dev": "nightly"}, false},
+		{"branch with slashes", []string{"release/next=rc"}, map[string]string{"release/next": "rc"}, false},
+		{"missing channel", []string{"beta="}, nil, true},
+		{"missing branch", []string{"=beta"}, nil, true},
+		{"no separator", []string{"beta"}, nil, true},
+		{"not usable in a version", []string{"beta=be ta"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChannels(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseChannels() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !maps.Equal(got, tt.want) {
+				t.Errorf("parseChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChannelVersion(t *testing.T) {
+	tests := []struct {
+		version   string
+		runNumber int
+		want      string
+		wantErr   bool
+	}{
+		{"1.2.0", 42, "1.2.0-beta.42", false},
+		{"1.2.0-alpha.1", 42, "1.2.0-alpha.1", false},
+		{"1.2.0", 0, "", true},
+	}
+	for _, tt := range tests {
+		got, err := channelVersion(tt.version, "beta", tt.runNumber)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("channelVersion(%q, %d) error = %v, wantErr %v", tt.version, tt.runNumber, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("channelVersion(%q, %d) = %q, want %q", tt.version, tt.runNumber, got, tt.want)
+		}
+	}
+}
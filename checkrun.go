@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// reportCheckRun records the outcome of a run as a completed check run on
+// the tagged commit, logging rather than returning failures. Runs that
+// failed before the commit was known have nothing to attach it to.
+func (r *releaser) reportCheckRun(ctx context.Context, sha string, res *runResult, runErr error) {
+	if sha == "" {
+		slog.Debug("No commit to report the check run on")
+		return
+	}
+
+	conclusion, title := "success", "Released"
+	var summary strings.Builder
+	switch {
+	case errors.Is(runErr, errCancelled) || ctx.Err() != nil:
+		conclusion, title = "cancelled", "Release cancelled"
+		summary.WriteString("The release was cancelled.")
+	case runErr != nil:
+		conclusion, title = "failure", "Release failed"
+		fmt.Fprintf(&summary, "The release failed:\n\n```\n%s\n```", runErr)
+	}
+
+	opt := github.CreateCheckRunOptions{
+		Name:       r.opts.checkRunName,
+		HeadSHA:    sha,
+		Status:     github.String("completed"),
+		Conclusion: github.String(conclusion),
+	}
+	if runErr == nil {
+		for _, rel := range res.Releases {
+			fmt.Fprintf(&summary, "- [%s](%s)\n", rel.Tag, rel.ReleaseURL)
+		}
+		if len(res.Releases) > 0 {
+			title = "Released " + res.Releases[0].Tag
+			opt.DetailsURL = github.String(res.Releases[0].ReleaseURL)
+		}
+	}
+	opt.Output = &github.CheckRunOutput{Title: github.String(title), Summary: github.String(strings.TrimSpace(summary.String()))}
+
+	check, _, err := r.client.Checks.CreateCheckRun(context.WithoutCancel(ctx), r.owner, r.repo, opt)
+	if err != nil {
+		slog.Warn("Failed to create check run", "sha", sha, "error", err)
+		return
+	}
+	slog.Info("Created check run", "name", r.opts.checkRunName, "conclusion", conclusion, "url", check.GetHTMLURL())
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// commitLogLimit is the most commits commitLogSection lists.
+const commitLogLimit = 100
+
+// commitLogSection lists the commits between the previous release's tag and
+// commitSHA, newest first and at most commitLogLimit of them. Pull request
+// references such as "(#12)" in the subjects are linked by GitHub when the
+// release body is rendered.
+func (r *releaser) commitLogSection(ctx context.Context, prev *github.RepositoryRelease, commitSHA string) (string, error) {
+	base := prev.GetTagName()
+	commits, err := r.compareCommits(ctx, base, commitSHA)
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", nil
+	}
+	more := max(len(commits)-commitLogLimit, 0)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Commits\n\nSince %s:\n", base)
+	for _, c := range slices.Backward(commits[more:]) {
+		subject, _, _ := strings.Cut(c.GetCommit().GetMessage(), "\n")
+		author := c.GetCommit().GetAuthor().GetName()
+		if login := c.GetAuthor().GetLogin(); login != "" {
+			author = "@" + login
+		}
+		fmt.Fprintf(&b, "\n- %s (%.7s) by %s", strings.TrimSpace(subject), c.GetSHA(), author)
+	}
+	if more > 0 {
+		// The previous release's URL gives the repository's, also on GitHub
+		// Enterprise Server.
+		repoURL, _, _ := strings.Cut(prev.GetHTMLURL(), "/releases/")
+		fmt.Fprintf(&b, "\n\n…and %d more, see %s/compare/%s...%s", more, repoURL, base, commitSHA)
+	}
+	return b.String(), nil
+}
+
+// compareCommits lists every commit between the base and head refs, oldest
+// first.
+func (r *releaser) compareCommits(ctx context.Context, base, head string) ([]*github.RepositoryCommit, error) {
+	var commits []*github.RepositoryCommit
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		cmp, resp, err := r.client.Repositories.CompareCommits(ctx, r.owner, r.repo, base, head, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s...%s: %w", base, head, err)
+		}
+		commits = append(commits, cmp.Commits...)
+		if resp.NextPage == 0 {
+			return commits, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// contributorsSection thanks the distinct authors of the commits between
+// the previous release's tag and commitSHA. Bots are left out, and authors
+// without a GitHub account are named instead of mentioned.
+func (r *releaser) contributorsSection(ctx context.Context, prev *github.RepositoryRelease, commitSHA string) (string, error) {
+	commits, err := r.compareCommits(ctx, prev.GetTagName(), commitSHA)
+	if err != nil {
+		return "", err
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, c := range commits {
+		name := c.GetCommit().GetAuthor().GetName()
+		if a := c.GetAuthor(); a != nil {
+			if a.GetType() == "Bot" || strings.HasSuffix(a.GetLogin(), "[bot]") {
+				continue
+			}
+			name = "@" + a.GetLogin()
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	return "### Thanks to\n\n" + strings.Join(names, ", "), nil
+}
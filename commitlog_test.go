@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// testGitHub returns a client for a fake GitHub API served by handler.
+func testGitHub(t *testing.T, handler http.Handler) *github.Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(srv.URL + "/")
+	return client
+}
+
+// servePages writes the page of items requested by r, per_page at a time,
+// with a Link header to the next page like the GitHub API.
+func servePages[T any](w http.ResponseWriter, r *http.Request, items []T, wrap func([]T) any) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	page, perPage = max(page, 1), max(perPage, 30)
+	start, end := min((page-1)*perPage, len(items)), min(page*perPage, len(items))
+	if end < len(items) {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.RequestURI()))
+	}
+	json.NewEncoder(w).Encode(wrap(items[start:end]))
+}
+
+func TestCommitLogSection(t *testing.T) {
+	var commits []*github.RepositoryCommit
+	for i := range 250 {
+		commits = append(commits, &github.RepositoryCommit{
+			SHA:    github.String(fmt.Sprintf("%07d", i)),
+			Commit: &github.Commit{Message: github.String(fmt.Sprintf("commit %d\n\nbody", i))},
+			Author: &github.User{Login: github.String("dev")},
+		})
+	}
+	prev := &github.RepositoryRelease{TagName: github.String("v1.0.0"), HTMLURL: github.String("https://github.com/owner/repo/releases/tag/v1.0.0")}
+
+	tests := []struct {
+		name      string
+		commits   int
+		wantFirst string
+		wantLast  string
+		wantMore  string
+	}{
+		{"few", 3, "commit 2", "commit 0", ""},
+		{"exactly the limit", commitLogLimit, "commit 99", "commit 0", ""},
+		{"more than a page", 250, "commit 249", "commit 150", "…and 150 more, see https://github.com/owner/repo/compare/v1.0.0...head"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := testGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				servePages(w, r, commits[:tt.commits], func(page []*github.RepositoryCommit) any {
+					return &github.CommitsComparison{Commits: page, TotalCommits: github.Int(tt.commits)}
+				})
+			}))
+			r := &releaser{client: client, owner: "owner", repo: "repo"}
+			section, err := r.commitLogSection(context.Background(), prev, "head")
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(section, "\n")
+			var listed []string
+			for _, l := range lines {
+				if strings.HasPrefix(l, "- ") {
+					listed = append(listed, l)
+				}
+			}
+			if want := min(tt.commits, commitLogLimit); len(listed) != want {
+				t.Fatalf("listed %d commits, want %d", len(listed), want)
+			}
+			if !strings.Contains(listed[0], tt.wantFirst+" ") || !strings.Contains(listed[len(listed)-1], tt.wantLast+" ") {
+				t.Errorf("listed %q to %q, want %q to %q", listed[0], listed[len(listed)-1], tt.wantFirst, tt.wantLast)
+			}
+			if got := strings.Contains(section, "more, see"); got != (tt.wantMore != "") || !strings.Contains(section, tt.wantMore) {
+				t.Errorf("section ends %q, want %q", lines[len(lines)-1], tt.wantMore)
+			}
+		})
+	}
+}
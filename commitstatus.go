@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// reportCommitStatus sets a commit status for the outcome of a run on the
+// tagged commit, for tooling that reads statuses rather than check runs.
+// Like reportCheckRun it only logs its failures.
+func (r *releaser) reportCommitStatus(ctx context.Context, sha string, res *runResult, runErr error) {
+	if sha == "" {
+		slog.Debug("No commit to set the status on")
+		return
+	}
+
+	status := &github.RepoStatus{Context: github.String(r.opts.commitStatusContext)}
+	switch {
+	case errors.Is(runErr, errCancelled) || ctx.Err() != nil:
+		status.State, status.Description = github.String("error"), github.String("Release cancelled")
+	case runErr != nil:
+		// Descriptions are limited to 140 characters.
+		msg := runErr.Error()
+		if runes := []rune(msg); len(runes) > 140 {
+			msg = string(runes[:137]) + "..."
+		}
+		status.State, status.Description = github.String("failure"), github.String(msg)
+	default:
+		status.State, status.Description = github.String("success"), github.String("Released")
+		if len(res.Releases) > 0 {
+			status.Description = github.String("Released " + res.Releases[0].Tag)
+			status.TargetURL = github.String(res.Releases[0].ReleaseURL)
+		}
+	}
+
+	if _, _, err := r.client.Repositories.CreateStatus(context.WithoutCancel(ctx), r.owner, r.repo, sha, status); err != nil {
+		slog.Warn("Failed to set commit status", "sha", sha, "error", err)
+		return
+	}
+	slog.Info("Set commit status", "context", r.opts.commitStatusContext, "state", status.GetState(), "sha", sha)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// platformNames are the display name and mod.json "gd" key of each binary
+// platform.
+var platformNames = map[string]struct{ name, gdKey string }{
+	"windows":   {"Windows", "win"},
+	"macos":     {"macOS", "mac"},
+	"ios":       {"iOS", "ios"},
+	"android32": {"Android (32-bit)", "android"},
+	"android64": {"Android (64-bit)", "android"},
+}
+
+// gdVersionFor returns the Geometry Dash version mod.json targets on the
+// given "gd" platform key, or "" if it does not target that platform.
+func (m *ModJSON) gdVersionFor(key string) string {
+	var single string
+	if err := json.Unmarshal(m.GD, &single); err == nil {
+		return single
+	}
+	var perPlatform map[string]string
+	if err := json.Unmarshal(m.GD, &perPlatform); err != nil {
+		return ""
+	}
+	return perPlatform[key]
+}
+
+// compatibilitySection renders which platforms pkg ships a binary for and
+// the Geometry Dash version it targets on each.
+func compatibilitySection(pkg *geodePackage) (string, error) {
+	present, err := packagePlatforms(pkg)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString("### Compatibility\n\n| Platform | Binary | Geometry Dash |\n| --- | --- | --- |")
+	for _, p := range platformOrder {
+		binary := "✗"
+		if slices.Contains(present, p) {
+			binary = "✓"
+		}
+		gd := pkg.mod.gdVersionFor(platformNames[p].gdKey)
+		switch gd {
+		case "":
+			gd = "—"
+		case "*":
+			gd = "any"
+		}
+		fmt.Fprintf(&b, "\n| %s | %s | %s |", platformNames[p].name, binary, gd)
+	}
+	return b.String(), nil
+}
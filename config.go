@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const defaultConfigFile = ".gwtreleaser.json"
+
+// applyConfigFile sets every flag in fs that was not given on the command
+// line from the JSON object in path. Keys are flag names; values may be
+// strings, numbers, booleans or, for repeatable flags, arrays. A missing file
+// is only an error if required is set.
+//
+// The "profiles" key holds named objects of the same settings. With profile
+// set, that profile's settings are applied first and so win over the
+// top-level ones.
+func applyConfigFile(flags *flag.FlagSet, path string, required bool, profile string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !required && profile == "" {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg map[string]json.RawMessage
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var profiles map[string]map[string]json.RawMessage
+	if raw, ok := cfg["profiles"]; ok {
+		if err := json.Unmarshal(raw, &profiles); err != nil {
+			return fmt.Errorf("%s: profiles: must be an object of profile objects: %w", path, err)
+		}
+		delete(cfg, "profiles")
+	}
+	if profile != "" {
+		settings, ok := profiles[profile]
+		if !ok {
+			return fmt.Errorf("%s: unknown profile %q (have %s)", path, profile, strings.Join(slices.Sorted(maps.Keys(profiles)), ", "))
+		}
+		slog.Debug("Using config profile", "profile", profile, "config", path)
+		if err := applyConfig(flags, settings, fmt.Sprintf("%s: profile %s", path, profile)); err != nil {
+			return err
+		}
+	}
+	return applyConfig(flags, cfg, path)
+}
+
+// applyConfig sets the flags named in cfg that were not set explicitly.
+func applyConfig(flags *flag.FlagSet, cfg map[string]json.RawMessage, source string) error {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for name, raw := range cfg {
+		if flags.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown setting %q", source, name)
+		}
+		if explicit[name] {
+			continue
+		}
+
+		values, err := configValues(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", source, name, err)
+		}
+		for _, v := range values {
+			if err := flags.Set(name, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", source, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configValues converts a JSON config value into flag values.
+func configValues(raw json.RawMessage) ([]string, error) {
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var values []string
+		for _, item := range list {
+			v, err := configScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	v, err := configScalar(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []string{v}, nil
+}
+
+func configScalar(raw json.RawMessage) (string, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", errors.New("must be a string, number, boolean or array of those")
+	}
+}
+
+// applyDefaults sets flags that have not been set by any earlier source from
+// defaults, which were derived from the environment described by source.
+func applyDefaults(flags *flag.FlagSet, defaults map[string]string, source string) error {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	for name, value := range defaults {
+		if explicit[name] || value == "" {
+			continue
+		}
+		slog.Debug("Using default from "+source, "flag", name, "value", value)
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %s: %w", source, name, err)
+		}
+	}
+	return nil
+}
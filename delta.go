@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// deltaPatch builds a VCDIFF patch from the package in the release before
+// pr to the new one, for updaters that already have the previous version.
+// It returns nil if there is no previous package.
+func (r *releaser) deltaPatch(ctx context.Context, pr *pendingRelease) (*extraAsset, error) {
+	prev, old, err := r.previousPackageOf(ctx, pr)
+	if err != nil || old == nil {
+		return nil, err
+	}
+
+	// The old version comes from the previous tag, normalized like pr.version.
+	from, err := versionFromTag(prev.GetTagName(), pr.tagPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the version of %s: %w", prev.GetTagName(), err)
+	}
+	patch := vcdiff(old.data, pr.pkg.data)
+	name := fmt.Sprintf("%s-%s-to-%s.vcdiff", pr.pkg.mod.ID, from, pr.version)
+	slog.Info("Built delta patch", "name", name, "from", prev.GetTagName(), "bytes", len(patch), "package_bytes", len(pr.pkg.data))
+	return &extraAsset{name: name, data: patch}, nil
+}
+
+const (
+	// vcdiffBlock is the shortest match worth a COPY instruction.
+	vcdiffBlock = 16
+	// vcdiffWindow bounds the target window so decoders such as xdelta3
+	// never need more than this much memory for it.
+	vcdiffWindow = 8 << 20
+)
+
+// vcdiff encodes target as an RFC 3284 delta against source that xdelta3
+// and other VCDIFF decoders can apply. Matches are found by hashing the
+// source in aligned blocks; the instructions use the default code table
+// without compression of the sections.
+func vcdiff(source, target []byte) []byte {
+	index := make(map[uint64]int)
+	for pos := 0; pos+vcdiffBlock <= len(source); pos += vcdiffBlock {
+		h := blockHash(source[pos : pos+vcdiffBlock])
+		if _, ok := index[h]; !ok {
+			index[h] = pos
+		}
+	}
+
+	out := []byte{0xd6, 0xc3, 0xc4, 0x00, 0x00}
+	for start := 0; start < len(target); start += vcdiffWindow {
+		end := min(start+vcdiffWindow, len(target))
+		out = appendVCDIFFWindow(out, source, target[start:end], index)
+	}
+	return out
+}
+
+func appendVCDIFFWindow(out, source, target []byte, index map[uint64]int) []byte {
+	var data, inst, addr []byte
+	add := func(b []byte) {
+		if len(b) > 0 {
+			inst = appendVarint(append(inst, 1), uint64(len(b))) // ADD, size follows
+			data = append(data, b...)
+		}
+	}
+
+	pending := 0
+	var h uint64
+	if len(target) >= vcdiffBlock {
+		h = blockHash(target[:vcdiffBlock])
+	}
+	for i := 0; i+vcdiffBlock <= len(target); {
+		if pos, ok := index[h]; ok && bytes.Equal(source[pos:pos+vcdiffBlock], target[i:i+vcdiffBlock]) {
+			s, t := pos, i
+			for s > 0 && t > pending && source[s-1] == target[t-1] {
+				s, t = s-1, t-1
+			}
+			e, f := pos+vcdiffBlock, i+vcdiffBlock
+			for e < len(source) && f < len(target) && source[e] == target[f] {
+				e, f = e+1, f+1
+			}
+			add(target[pending:t])
+			inst = appendVarint(append(inst, 19), uint64(f-t)) // COPY in VCD_SELF mode, size follows
+			addr = appendVarint(addr, uint64(s))
+			i, pending = f, f
+			if i+vcdiffBlock <= len(target) {
+				h = blockHash(target[i : i+vcdiffBlock])
+			}
+			continue
+		}
+		if i+vcdiffBlock < len(target) {
+			h = rollHash(h, target[i], target[i+vcdiffBlock])
+		}
+		i++
+	}
+	add(target[pending:])
+
+	var delta []byte
+	delta = appendVarint(delta, uint64(len(target)))
+	delta = append(delta, 0) // no secondary compression
+	delta = appendVarint(delta, uint64(len(data)))
+	delta = appendVarint(delta, uint64(len(inst)))
+	delta = appendVarint(delta, uint64(len(addr)))
+	delta = append(append(append(delta, data...), inst...), addr...)
+
+	if len(source) > 0 {
+		out = append(out, 0x01) // VCD_SOURCE
+		out = appendVarint(out, uint64(len(source)))
+		out = appendVarint(out, 0)
+	} else {
+		out = append(out, 0x00)
+	}
+	out = appendVarint(out, uint64(len(delta)))
+	return append(out, delta...)
+}
+
+// appendVarint appends v in the VCDIFF integer encoding: base 128, most
+// significant digit first, with the top bit set on all but the last byte.
+func appendVarint(b []byte, v uint64) []byte {
+	var tmp [10]byte
+	n := len(tmp) - 1
+	tmp[n] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		n--
+		tmp[n] = byte(v&0x7f) | 0x80
+	}
+	return append(b, tmp[n:]...)
+}
+
+const hashBase = 1099511628211
+
+// hashOut is hashBase to the power vcdiffBlock-1, the weight of the byte
+// leaving the rolling hash.
+var hashOut = func() uint64 {
+	p := uint64(1)
+	for range vcdiffBlock - 1 {
+		p *= hashBase
+	}
+	return p
+}()
+
+func blockHash(b []byte) uint64 {
+	var h uint64
+	for _, c := range b {
+		h = h*hashBase + uint64(c)
+	}
+	return h
+}
+
+func rollHash(h uint64, out, in byte) uint64 {
+	return (h-uint64(out)*hashOut)*hashBase + uint64(in)
+}
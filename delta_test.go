@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// decodeVCDIFF applies a patch made by vcdiff to source. It understands
+// only the instructions vcdiff emits.
+func decodeVCDIFF(source, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte{0xd6, 0xc3, 0xc4, 0x00, 0x00}) {
+		return nil, errors.New("bad header")
+	}
+	p := patch[5:]
+	varint := func(b *[]byte) (uint64, error) {
+		var v uint64
+		for i, c := range *b {
+			v = v<<7 | uint64(c&0x7f)
+			if c&0x80 == 0 {
+				*b = (*b)[i+1:]
+				return v, nil
+			}
+		}
+		return 0, errors.New("truncated integer")
+	}
+	take := func(b *[]byte, n uint64) ([]byte, error) {
+		if uint64(len(*b)) < n {
+			return nil, errors.New("truncated section")
+		}
+		s := (*b)[:n]
+		*b = (*b)[n:]
+		return s, nil
+	}
+
+	var out []byte
+	for len(p) > 0 {
+		indicator := p[0]
+		p = p[1:]
+		var segment []byte
+		if indicator&0x01 != 0 {
+			size, err := varint(&p)
+			if err != nil {
+				return nil, err
+			}
+			pos, err := varint(&p)
+			if err != nil {
+				return nil, err
+			}
+			if pos+size > uint64(len(source)) {
+				return nil, errors.New("source segment out of range")
+			}
+			segment = source[pos : pos+size]
+		}
+		deltaLen, err := varint(&p)
+		if err != nil {
+			return nil, err
+		}
+		delta, err := take(&p, deltaLen)
+		if err != nil {
+			return nil, err
+		}
+
+		targetLen, err := varint(&delta)
+		if err != nil {
+			return nil, err
+		}
+		if len(delta) == 0 || delta[0] != 0 {
+			return nil, errors.New("unexpected delta indicator")
+		}
+		delta = delta[1:]
+		var lens [3]uint64
+		for i := range lens {
+			if lens[i], err = varint(&delta); err != nil {
+				return nil, err
+			}
+		}
+		if uint64(len(delta)) != lens[0]+lens[1]+lens[2] {
+			return nil, errors.New("section lengths do not add up")
+		}
+		data, inst, addr := delta[:lens[0]], delta[lens[0]:lens[0]+lens[1]], delta[lens[0]+lens[1]:]
+
+		var window []byte
+		for len(inst) > 0 {
+			code := inst[0]
+			inst = inst[1:]
+			size, err := varint(&inst)
+			if err != nil {
+				return nil, err
+			}
+			switch code {
+			case 1:
+				b, err := take(&data, size)
+				if err != nil {
+					return nil, err
+				}
+				window = append(window, b...)
+			case 19:
+				a, err := varint(&addr)
+				if err != nil {
+					return nil, err
+				}
+				if a+size > uint64(len(segment)) {
+					return nil, errors.New("copy out of the source segment")
+				}
+				window = append(window, segment[a:a+size]...)
+			default:
+				return nil, fmt.Errorf("unexpected instruction %d", code)
+			}
+		}
+		if uint64(len(window)) != targetLen {
+			return nil, fmt.Errorf("window decoded to %d bytes, want %d", len(window), targetLen)
+		}
+		out = append(out, window...)
+	}
+	return out, nil
+}
+
+func TestVCDIFFRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+
+	base := random(64 << 10)
+	edited := bytes.Clone(base)
+	copy(edited[1000:], "changed")
+	edited = append(edited[:30000], append(random(500), edited[30000:]...)...)
+
+	large := random(vcdiffWindow + vcdiffWindow/2)
+	largeEdited := bytes.Clone(large)
+	copy(largeEdited[vcdiffWindow-100:], random(50))
+
+	tests := []struct {
+		name           string
+		source, target []byte
+		// similar is set when the patch should be mostly copies.
+		similar bool
+	}{
+		{"empty source", nil, random(1000), false},
+		{"empty target", base, nil, false},
+		{"both empty", nil, nil, false},
+		{"identical", base, base, true},
+		{"edited", base, edited, true},
+		{"shorter than a block", []byte("abc"), []byte("abd"), false},
+		{"longer than a window", nil, random(vcdiffWindow + 1000), false},
+		{"matches across windows", large, largeEdited, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := vcdiff(tt.source, tt.target)
+			got, err := decodeVCDIFF(tt.source, patch)
+			if err != nil {
+				t.Fatalf("decodeVCDIFF: %v", err)
+			}
+			if !bytes.Equal(got, tt.target) {
+				t.Fatalf("decoded %d bytes that differ from the %d byte target", len(got), len(tt.target))
+			}
+			if tt.similar && len(patch) > len(tt.target)/10 {
+				t.Errorf("patch is %d bytes for a %d byte target", len(patch), len(tt.target))
+			}
+			checkWithXdelta3(t, tt.source, tt.target, patch)
+		})
+	}
+}
+
+// checkWithXdelta3 also applies patch with xdelta3, if it is installed.
+func checkWithXdelta3(t *testing.T, source, target, patch []byte) {
+	xdelta3, err := exec.LookPath("xdelta3")
+	if err != nil {
+		return
+	}
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	src, delta, out := write("source", source), write("patch", patch), filepath.Join(dir, "out")
+	if msg, err := exec.Command(xdelta3, "-d", "-f", "-s", src, delta, out).CombinedOutput(); err != nil {
+		t.Fatalf("xdelta3: %v\n%s", err, msg)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, target) {
+		t.Fatalf("xdelta3 decoded %d bytes that differ from the %d byte target", len(got), len(target))
+	}
+}
+
+func TestAppendVarint(t *testing.T) {
+	tests := []struct {
+		v    uint64
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x81, 0x00}},
+		{123456789, []byte{0xba, 0xef, 0x9a, 0x15}},
+	}
+	for _, tt := range tests {
+		if got := appendVarint(nil, tt.v); !bytes.Equal(got, tt.want) {
+			t.Errorf("appendVarint(%d) = %x, want %x", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestRollHash(t *testing.T) {
+	b := []byte("the quick brown fox jumps over the lazy dog")
+	h := blockHash(b[:vcdiffBlock])
+	for i := 0; i+vcdiffBlock < len(b); i++ {
+		h = rollHash(h, b[i], b[i+vcdiffBlock])
+		if want := blockHash(b[i+1 : i+1+vcdiffBlock]); h != want {
+			t.Fatalf("rolled hash at %d = %x, want %x", i+1, h, want)
+		}
+	}
+}
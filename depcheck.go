@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// dependencyError lists the dependencies of a package that cannot be
+// installed from the index.
+type dependencyError struct {
+	modID    string
+	problems []string
+}
+
+func (e *dependencyError) Error() string {
+	return e.modID + " would not be installable, its dependencies are missing from the Geode index:\n  - " + strings.Join(e.problems, "\n  - ")
+}
+
+// modVersions lists the versions of modID on the index, or nil if the index
+// does not know the mod.
+func (c *indexClient) modVersions(ctx context.Context, modID string) ([]string, error) {
+	var versions []string
+	for page := 1; ; page++ {
+		var payload struct {
+			Count int `json:"count"`
+			Data  []struct {
+				Version string `json:"version"`
+			} `json:"data"`
+		}
+		err := c.get(ctx, fmt.Sprintf("/v1/mods/%s/versions?per_page=100&page=%d", url.PathEscape(modID), page), &payload)
+		var ie *indexError
+		if errors.As(err, &ie) && ie.status == http.StatusNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range payload.Data {
+			versions = append(versions, v.Version)
+		}
+		if len(payload.Data) == 0 || len(versions) >= payload.Count {
+			return versions, nil
+		}
+	}
+}
+
+// checkDependencies verifies that every dependency in pkg's mod.json is on
+// the index at a version satisfying its requirement. Missing required
+// dependencies fail the check; the others are only warned about. The loader
+// itself is not on the index and is skipped.
+func (r *releaser) checkDependencies(ctx context.Context, pkg *geodePackage) error {
+	deps, err := decodeDependencies(pkg.mod.Dependencies)
+	if err != nil {
+		return fmt.Errorf("failed to read dependencies: %w", err)
+	}
+
+	index := &indexClient{baseURL: r.opts.indexURL, http: r.http}
+	var problems []string
+	for _, dep := range deps {
+		if dep.ID == "geode.loader" {
+			continue
+		}
+		versions, err := index.modVersions(ctx, dep.ID)
+		if err != nil {
+			return fmt.Errorf("failed to look up dependency %s on the Geode index: %w", dep.ID, err)
+		}
+
+		var problem string
+		switch {
+		case versions == nil:
+			problem = fmt.Sprintf("%s: not on the index", dep.ID)
+		case !anySatisfies(versions, dep.Version):
+			problem = fmt.Sprintf("%s: no version on the index satisfies %s (has %s)", dep.ID, dep.Version, strings.Join(versions, ", "))
+		default:
+			slog.Debug("Dependency found on the Geode index", "mod_id", pkg.mod.ID, "dependency", dep.ID, "version", dep.Version)
+			continue
+		}
+		if dep.Importance != "" && dep.Importance != "required" {
+			slog.Warn("Optional dependency is not available", "mod_id", pkg.mod.ID, "importance", dep.Importance, "problem", problem)
+			continue
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) > 0 {
+		return &dependencyError{modID: pkg.mod.ID, problems: problems}
+	}
+	slog.Info("Dependencies are available on the Geode index", "mod_id", pkg.mod.ID, "dependencies", len(deps))
+	return nil
+}
+
+// anySatisfies reports whether one of versions meets the requirement req.
+// As in Geode, a requirement without an operator means at least that
+// version, and only = and the upper bounds may cross a major version.
+func anySatisfies(versions []string, req string) bool {
+	if req == "*" {
+		return len(versions) > 0
+	}
+	op := req[:len(req)-len(strings.TrimLeft(req, "<>="))]
+	want, err := normalizeVersion(strings.TrimPrefix(req, op))
+	if err != nil {
+		return false
+	}
+	for _, v := range versions {
+		have, err := normalizeVersion(v)
+		if err != nil {
+			continue
+		}
+		c := compareVersions(have, want)
+		sameMajor := semver.Major("v"+have) == semver.Major("v"+want)
+		switch op {
+		case "", ">=":
+			if c >= 0 && sameMajor {
+				return true
+			}
+		case ">":
+			if c > 0 && sameMajor {
+				return true
+			}
+		case "=":
+			if c == 0 {
+				return true
+			}
+		case "<=":
+			if c <= 0 {
+				return true
+			}
+		case "<":
+			if c < 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestAnySatisfies(t *testing.T) {
+	versions := []string{"v1.2.0", "1.4.1", "2.0.0-beta.1", "not-a-version"}
+	tests := []struct {
+		req  string
+		want bool
+	}{
+		{"*", true},
+		{"1.2.0", true},
+		{"v1.3.0", true},
+		{"1.5.0", false},
+		{">=1.4.1", true},
+		{">=1.4.2", false},
+		{">1.4.0", true},
+		{">1.4.1", false},
+		{"=1.2.0", true},
+		{"=1.3.0", false},
+		{"=2.0.0-beta.1", true},
+		{"<=1.2.0", true},
+		{"<1.2.0", false},
+		{"<3.0.0", true},
+		// Lower bounds stay within the major version.
+		{">=0.9.0", false},
+		{">=2.0.0-alpha", true},
+		{"", false},
+		{"~1.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := anySatisfies(versions, tt.req); got != tt.want {
+			t.Errorf("anySatisfies(%q) = %v, want %v", tt.req, got, tt.want)
+		}
+	}
+	if anySatisfies(nil, "*") {
+		t.Error(`anySatisfies(nil, "*") = true, want false`)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// startDeployment creates a deployment of sha to the -deployment-environment
+// environment and marks it in progress. Commit statuses are not required,
+// since the release is what produces them; environment protection rules
+// still apply and reject the deployment, failing the release.
+func (r *releaser) startDeployment(ctx context.Context, sha string) (*github.Deployment, error) {
+	env := r.opts.deploymentEnvironment
+	d, _, err := r.client.Repositories.CreateDeployment(ctx, r.owner, r.repo, &github.DeploymentRequest{
+		Ref:              github.String(sha),
+		Task:             github.String("release"),
+		AutoMerge:        github.Bool(false),
+		RequiredContexts: &[]string{},
+		Environment:      github.String(env),
+		Description:      github.String("Release by gwtreleaser"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deployment to %s: %w", env, err)
+	}
+	slog.Info("Created deployment", "environment", env, "deployment_id", d.GetID(), "sha", sha)
+
+	r.deploymentStatus(ctx, d, &github.DeploymentStatusRequest{State: github.String("in_progress"), Description: github.String("Releasing")})
+	return d, nil
+}
+
+// finishDeployment posts the outcome of the run as the final status of d,
+// pointing the environment at the release.
+func (r *releaser) finishDeployment(ctx context.Context, d *github.Deployment, res *runResult, runErr error) {
+	status := &github.DeploymentStatusRequest{AutoInactive: github.Bool(true)}
+	switch {
+	case errors.Is(runErr, errCancelled) || ctx.Err() != nil:
+		status.State, status.Description = github.String("error"), github.String("Release cancelled")
+	case runErr != nil:
+		status.State, status.Description = github.String("failure"), github.String("Release failed")
+	default:
+		status.State, status.Description = github.String("success"), github.String("Released")
+		if len(res.Releases) > 0 {
+			status.Description = github.String("Released " + res.Releases[0].Tag)
+			status.EnvironmentURL = github.String(res.Releases[0].ReleaseURL)
+		}
+	}
+	r.deploymentStatus(context.WithoutCancel(ctx), d, status)
+}
+
+// deploymentStatus posts status on d, logging rather than returning
+// failures.
+func (r *releaser) deploymentStatus(ctx context.Context, d *github.Deployment, status *github.DeploymentStatusRequest) {
+	if r.source != nil {
+		status.LogURL = github.String(r.source.GetHTMLURL())
+	}
+	if _, _, err := r.client.Repositories.CreateDeploymentStatus(ctx, r.owner, r.repo, d.GetID(), status); err != nil {
+		slog.Warn("Failed to post deployment status", "deployment_id", d.GetID(), "state", status.GetState(), "error", err)
+		return
+	}
+	slog.Debug("Posted deployment status", "deployment_id", d.GetID(), "state", status.GetState())
+}
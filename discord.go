@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Geode's brand colour, used for the embed accent.
+const discordEmbedColor = 0xF7C032
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	URL         string              `json:"url,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordMessage struct {
+	Embeds []discordEmbed `json:"embeds"`
+}
+
+// notifyDiscord posts a release embed to a Discord webhook.
+func notifyDiscord(ctx context.Context, client *http.Client, webhookURL string, a *announcement) error {
+	embed := discordEmbed{
+		Title:       fmt.Sprintf("%s %s released", a.ModName, a.Tag),
+		URL:         a.ReleaseURL,
+		Description: a.Changelog,
+		Color:       discordEmbedColor,
+		Fields: []discordEmbedField{
+			{Name: "Version", Value: a.Version, Inline: true},
+			{Name: "Mod ID", Value: "`" + a.ModID + "`", Inline: true},
+		},
+	}
+	if len(a.Developers) > 0 {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Developers", Value: strings.Join(a.Developers, ", "), Inline: true})
+	}
+	if a.DownloadURL != "" {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: "Download", Value: fmt.Sprintf("[%s](%s)", a.ModID+".geode", a.DownloadURL)})
+	}
+
+	return postJSON(ctx, client, webhookURL, discordMessage{Embeds: []discordEmbed{embed}}, nil)
+}
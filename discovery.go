@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const discoveryPollInterval = 10 * time.Second
+
+// retryDiscovery calls find until it returns something other than a "no
+// runs" or "artifact not found" error, or until window has passed. Right
+// after a workflow completes the API does not always list its runs and
+// artifacts yet.
+func retryDiscovery[T any](ctx context.Context, window time.Duration, find func() (T, error)) (T, error) {
+	deadline := time.Now().Add(window)
+	for {
+		v, err := find()
+		if err == nil {
+			return v, nil
+		}
+		code := exitCodeOf(err)
+		if code != exitNoRuns && code != exitArtifactNotFound || time.Now().Add(discoveryPollInterval).After(deadline) {
+			return v, err
+		}
+		slog.Info("Not found yet, retrying", "error", err, "retry_in", discoveryPollInterval, "until", deadline.Format(time.TimeOnly))
+		if err := sleepContext(ctx, discoveryPollInterval); err != nil {
+			return v, err
+		}
+	}
+}
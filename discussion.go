@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+const discussionCategoriesQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    id
+    discussionCategories(first: 100) {
+      nodes { id name }
+    }
+  }
+}`
+
+const createDiscussionMutation = `mutation($repositoryId: ID!, $categoryId: ID!, $title: String!, $body: String!) {
+  createDiscussion(input: {repositoryId: $repositoryId, categoryId: $categoryId, title: $title, body: $body}) {
+    discussion { url }
+  }
+}`
+
+// discussionCategory resolves the repository node ID and the ID of the
+// discussion category called name.
+func discussionCategory(ctx context.Context, client *github.Client, owner, repo, name string) (repoID, categoryID string, err error) {
+	var data struct {
+		Repository struct {
+			ID                   string `json:"id"`
+			DiscussionCategories struct {
+				Nodes []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"discussionCategories"`
+		} `json:"repository"`
+	}
+	if err := graphQL(ctx, client, discussionCategoriesQuery, map[string]any{"owner": owner, "name": repo}, &data); err != nil {
+		return "", "", fmt.Errorf("failed to list discussion categories: %w", err)
+	}
+
+	var names []string
+	for _, c := range data.Repository.DiscussionCategories.Nodes {
+		if strings.EqualFold(c.Name, name) {
+			return data.Repository.ID, c.ID, nil
+		}
+		names = append(names, c.Name)
+	}
+	if len(names) == 0 {
+		return "", "", fmt.Errorf("discussions are not enabled for %s/%s", owner, repo)
+	}
+	return "", "", fmt.Errorf("no discussion category %q (have %s)", name, strings.Join(names, ", "))
+}
+
+// postDiscussionAnnouncement opens a discussion thread announcing a release
+// in the given category and returns its URL.
+func postDiscussionAnnouncement(ctx context.Context, client *github.Client, owner, repo, category string, a *announcement) (string, error) {
+	repoID, categoryID, err := discussionCategory(ctx, client, owner, repo, category)
+	if err != nil {
+		return "", err
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "**%s %s** has been released.\n\n", a.ModName, a.Tag)
+	if a.Changelog != "" {
+		fmt.Fprintf(&body, "## Changes\n\n%s\n\n", a.Changelog)
+	}
+	fmt.Fprintf(&body, "[Release notes](%s)", a.ReleaseURL)
+	if a.DownloadURL != "" {
+		fmt.Fprintf(&body, " · [Download](%s)", a.DownloadURL)
+	}
+	body.WriteString("\n")
+
+	var data struct {
+		CreateDiscussion struct {
+			Discussion struct {
+				URL string `json:"url"`
+			} `json:"discussion"`
+		} `json:"createDiscussion"`
+	}
+	err = graphQL(ctx, client, createDiscussionMutation, map[string]any{
+		"repositoryId": repoID,
+		"categoryId":   categoryID,
+		"title":        fmt.Sprintf("%s %s released", a.ModName, a.Tag),
+		"body":         body.String(),
+	}, &data)
+	if err != nil {
+		return "", fmt.Errorf("failed to create discussion: %w", err)
+	}
+	return data.CreateDiscussion.Discussion.URL, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+const maxRetryBackoff = 30 * time.Second
+
+// urlResolver returns a fresh download URL. Artifact downloads go through
+// short-lived signed URLs, so one is requested per attempt.
+type urlResolver func(ctx context.Context) (string, error)
+
+// downloadWithResume streams the file behind resolve into dst. When a
+// transfer is interrupted it picks up from the bytes already written using an
+// HTTP Range request instead of starting over, giving up after retries failed
+// attempts.
+func downloadWithResume(ctx context.Context, client *http.Client, resolve urlResolver, dst *os.File, retries int) (int64, error) {
+	var written, total int64 = 0, -1
+	backoff := time.Second
+	p := newProgress("Downloading artifact", -1)
+
+	for attempt := 0; ; attempt++ {
+		var done bool
+		url, err := resolve(ctx)
+		if err == nil {
+			done, err = downloadAttempt(ctx, client, url, dst, &written, &total, p)
+		}
+		if done {
+			p.finish()
+			return written, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("transfer ended after %d bytes", written)
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) || attempt >= retries {
+			return written, err
+		}
+
+		slog.Warn("Download attempt failed, retrying", "attempt", attempt+1, "bytes", written, "error", err, "backoff", backoff)
+		if err := sleepContext(ctx, backoff); err != nil {
+			return written, err
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+}
+
+// downloadAttempt performs a single (possibly ranged) GET of url, appending to
+// dst from *written onwards. It reports done once the full body has been
+// received.
+func downloadAttempt(ctx context.Context, client *http.Client, url string, dst *os.File, written, total *int64, p *progress) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, &permanentError{err}
+	}
+	if *written > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", *written))
+		slog.Debug("Resuming download", "offset", *written)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		if t := contentRangeTotal(resp.Header.Get("Content-Range")); t >= 0 {
+			*total = t
+			p.total = t
+		}
+	case resp.StatusCode == http.StatusOK:
+		// A full response either starts the transfer or means the server
+		// ignored our Range header, in which case we have to start over.
+		if *written > 0 {
+			slog.Debug("Server does not support range requests, restarting download")
+		}
+		if err := resetFile(dst); err != nil {
+			return false, &permanentError{err}
+		}
+		*written = 0
+		*total = resp.ContentLength
+		p.reset(resp.ContentLength)
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && *written > 0 && *written == *total:
+		return true, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		// The signed URL has most likely expired; the next attempt
+		// resolves a new one.
+		return false, fmt.Errorf("download URL rejected: %s", resp.Status)
+	case resp.StatusCode >= 500:
+		return false, fmt.Errorf("unexpected status: %s", resp.Status)
+	default:
+		return false, &permanentError{fmt.Errorf("unexpected status: %s", resp.Status)}
+	}
+
+	n, err := io.Copy(io.MultiWriter(dst, p), resp.Body)
+	*written += n
+	if err != nil {
+		return false, err
+	}
+
+	return *total < 0 || *written >= *total, nil
+}
+
+// contentRangeTotal returns the complete length from a Content-Range header
+// such as "bytes 100-199/200", or -1 if it is absent or unknown.
+func contentRangeTotal(header string) int64 {
+	_, size, ok := strings.Cut(header, "/")
+	if !ok || size == "*" {
+		return -1
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func resetFile(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
+// permanentError marks a failure that retrying will not fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// artifactURLResolver resolves the signed download URL of an Actions artifact
+// through the authenticated API. The bytes themselves are then fetched without
+// the token, which blob storage would reject.
+func artifactURLResolver(client *github.Client, owner, repo string, artifactID int64) urlResolver {
+	return func(ctx context.Context) (string, error) {
+		slog.Debug("Getting artifact download URL", "artifact_id", artifactID)
+		u, resp, err := client.Actions.DownloadArtifact(ctx, owner, repo, artifactID, true)
+		if err != nil {
+			if resp != nil && resp.StatusCode >= 400 && resp.StatusCode < 500 {
+				return "", &permanentError{fmt.Errorf("failed to get artifact download URL: %w", err)}
+			}
+			return "", fmt.Errorf("failed to get artifact download URL: %w", err)
+		}
+		return u.String(), nil
+	}
+}
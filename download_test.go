@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestContentRangeTotal(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{"bytes 100-199/200", 200},
+		{"bytes 0-0/1", 1},
+		{"bytes 100-199/*", -1},
+		{"", -1},
+		{"bytes 100-199", -1},
+		{"bytes 100-199/abc", -1},
+	}
+	for _, tt := range tests {
+		if got := contentRangeTotal(tt.header); got != tt.want {
+			t.Errorf("contentRangeTotal(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadWithResume(t *testing.T) {
+	showProgress = false
+	body := bytes.Repeat([]byte("0123456789"), 1000)
+	tests := []struct {
+		name string
+		// ranges is whether the server honors Range requests.
+		ranges bool
+		// wantRequests is the number of GETs the download should take.
+		wantRequests int32
+	}{
+		{"resumes with a range request", true, 2},
+		{"restarts without range support", false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+				start := 0
+				if rng := r.Header.Get("Range"); rng != "" && tt.ranges {
+					start, _ = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+					w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(body)-1, len(body)))
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)-start))
+					w.WriteHeader(http.StatusPartialContent)
+				} else {
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				}
+				if n == 1 {
+					// Cut the first transfer off halfway.
+					w.Write(body[:len(body)/2])
+					w.(http.Flusher).Flush()
+					panic(http.ErrAbortHandler)
+				}
+				w.Write(body[start:])
+			}))
+			defer srv.Close()
+
+			dst, err := os.Create(filepath.Join(t.TempDir(), "artifact.zip"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dst.Close()
+
+			resolve := func(context.Context) (string, error) { return srv.URL, nil }
+			n, err := downloadWithResume(context.Background(), srv.Client(), resolve, dst, 3)
+			if err != nil {
+				t.Fatalf("downloadWithResume: %v", err)
+			}
+			if n != int64(len(body)) {
+				t.Errorf("downloaded %d bytes, want %d", n, len(body))
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("made %d requests, want %d", got, tt.wantRequests)
+			}
+			dst.Seek(0, io.SeekStart)
+			if got, _ := io.ReadAll(dst); !bytes.Equal(got, body) {
+				t.Errorf("downloaded file differs from the served body")
+			}
+		})
+	}
+}
+
+func TestDownloadWithResumePermanentError(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	dst, err := os.Create(filepath.Join(t.TempDir(), "artifact.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	resolve := func(context.Context) (string, error) { return srv.URL, nil }
+	if _, err := downloadWithResume(context.Background(), srv.Client(), resolve, dst, 3); err == nil {
+		t.Fatal("downloadWithResume succeeded on a 404")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests for a permanent failure, want 1", got)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultEmailTemplate = `{{.ModName}} {{.Tag}} has been released in {{.Repo}}.
+
+Release: {{.ReleaseURL}}
+{{- if .DownloadURL}}
+Download: {{.DownloadURL}}
+{{- end}}
+{{- if .Changelog}}
+
+Changes:
+{{.Changelog}}
+{{- end}}
+`
+
+// smtpConfig holds the settings for release summary emails.
+type smtpConfig struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	to       stringList
+	template string
+}
+
+func (c *smtpConfig) enabled() bool {
+	return c.host != "" && len(c.to) > 0
+}
+
+// emailSubject is the Subject header value for a. The mod name and tag come
+// from the package, so line breaks are removed to keep them from adding
+// headers, and non-ASCII text is encoded per RFC 2047.
+func emailSubject(a *announcement) string {
+	subject := fmt.Sprintf("[%s] %s %s released", a.Repo, a.ModName, a.Tag)
+	subject = strings.Join(strings.FieldsFunc(subject, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+	return mime.QEncoding.Encode("utf-8", subject)
+}
+
+// sendEmail mails a release summary for a. Port 465 uses implicit TLS;
+// other ports upgrade with STARTTLS when the server offers it.
+func sendEmail(cfg *smtpConfig, a *announcement) error {
+	if cfg.from == "" {
+		return errors.New("an SMTP sender address is required")
+	}
+
+	body, err := renderTemplate("email", cfg.template, a)
+	if err != nil {
+		return err
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", emailSubject(a))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	addr := net.JoinHostPort(cfg.host, strconv.Itoa(cfg.port))
+	var auth smtp.Auth
+	if cfg.username != "" {
+		auth = smtp.PlainAuth("", cfg.username, cfg.password, cfg.host)
+	}
+
+	if cfg.port != 465 {
+		return smtp.SendMail(addr, auth, cfg.from, cfg.to, []byte(msg.String()))
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: cfg.host})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, cfg.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(cfg.from); err != nil {
+		return err
+	}
+	for _, rcpt := range cfg.to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(msg.String())); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestEmailSubject(t *testing.T) {
+	tests := []struct {
+		name string
+		a    announcement
+		want string
+	}{
+		{"plain", announcement{Repo: "o/r", ModName: "Mod", Tag: "v1.0.0"}, "[o/r] Mod v1.0.0 released"},
+		{"header injection", announcement{Repo: "o/r", ModName: "Mod\r\nBcc: x@example.com", Tag: "v1.0.0"}, "[o/r] Mod Bcc: x@example.com v1.0.0 released"},
+		{"non-ASCII", announcement{Repo: "o/r", ModName: "Módé", Tag: "v1.0.0"}, "=?utf-8?q?[o/r]_M=C3=B3d=C3=A9_v1.0.0_released?="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := emailSubject(&tt.a); got != tt.want {
+				t.Errorf("emailSubject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// Exit codes. Wrapping scripts can branch on these to tell failure classes
+// apart; anything not listed here exits with exitFailure.
+const (
+	exitFailure          = 1 // unclassified failure
+	exitUsage            = 2 // invalid command-line usage
+	exitNoRuns           = 3 // no completed workflow runs matched
+	exitArtifactNotFound = 4 // the run has no matching artifact
+	exitTagExists        = 5 // the tag for the version already exists
+	exitAuth             = 6 // missing, invalid or insufficient credentials
+	exitUploadFailed     = 7 // the release asset could not be uploaded
+	exitMalware          = 8 // the malware scan flagged the package
+)
+
+const exitCodeUsage = `
+Exit codes:
+  0  success
+  1  unclassified failure
+  2  invalid command-line usage
+  3  no completed workflow runs found
+  4  artifact not found for the selected run
+  5  tag already exists
+  6  authentication or permission error
+  7  release asset upload failed
+  8  malware scan flagged the package
+`
+
+// exitError attaches an exit code to an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitCodeOf maps err onto the exit code taxonomy. Explicitly tagged errors
+// win; otherwise GitHub API authentication failures are recognised by status.
+func exitCodeOf(err error) int {
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	if isStatus(err, http.StatusUnauthorized) || isStatus(err, http.StatusForbidden) {
+		return exitAuth
+	}
+	return exitFailure
+}
+
+// isStatus reports whether err is a GitHub API error response with the given
+// HTTP status code.
+func isStatus(err error, status int) bool {
+	var er *github.ErrorResponse
+	return errors.As(err, &er) && er.Response != nil && er.Response.StatusCode == status
+}
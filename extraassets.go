@@ -0,0 +1,104 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// extraAsset is an additional file uploaded next to the .geode package.
+type extraAsset struct {
+	name string
+	data []byte
+}
+
+// collectExtraAssets resolves the -extra-asset glob patterns. Each pattern is
+// matched against the entries of the build artifact first, by full path or
+// base name, and against the local filesystem if nothing in the artifact
+// matches. A pattern that matches nothing is an error.
+func collectExtraAssets(patterns []string, zipData []byte) ([]extraAsset, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	var files []*zip.File
+	if zipData != nil {
+		zr, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip reader: %w", err)
+		}
+		files = zr.File
+	}
+
+	var assets []extraAsset
+	seen := make(map[string]string)
+	add := func(name, source string, data []byte) error {
+		if prev, ok := seen[name]; ok {
+			return fmt.Errorf("extra assets %s and %s would both be uploaded as %s", prev, source, name)
+		}
+		seen[name] = source
+		slog.Debug("Adding extra asset", "name", name, "source", source, "bytes", len(data))
+		assets = append(assets, extraAsset{name: name, data: data})
+		return nil
+	}
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid extra asset pattern %q: %w", pattern, err)
+		}
+
+		matched := false
+		for _, f := range files {
+			if strings.HasSuffix(f.Name, "/") || strings.HasSuffix(f.Name, ".geode") {
+				continue
+			}
+			full, _ := path.Match(pattern, f.Name)
+			base, _ := path.Match(pattern, path.Base(f.Name))
+			if !full && !base {
+				continue
+			}
+			data, err := readZipFile(f)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s from artifact: %w", f.Name, err)
+			}
+			if err := add(path.Base(f.Name), "artifact:"+f.Name, data); err != nil {
+				return nil, err
+			}
+			matched = true
+		}
+		if matched {
+			continue
+		}
+
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extra asset pattern %q: %w", pattern, err)
+		}
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err != nil {
+				return nil, err
+			}
+			if info.IsDir() {
+				continue
+			}
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read extra asset: %w", err)
+			}
+			if err := add(filepath.Base(p), p, data); err != nil {
+				return nil, err
+			}
+			matched = true
+		}
+		if !matched {
+			return nil, fmt.Errorf("extra asset pattern %q matched no files in the artifact or on disk", pattern)
+		}
+	}
+	return assets, nil
+}
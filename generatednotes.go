@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// generateNotesRequest is the body of the generate-notes endpoint, which
+// go-github's GenerateNotesOptions lacks configuration_file_path for.
+type generateNotesRequest struct {
+	TagName               string `json:"tag_name"`
+	TargetCommitish       string `json:"target_commitish,omitempty"`
+	PreviousTagName       string `json:"previous_tag_name,omitempty"`
+	ConfigurationFilePath string `json:"configuration_file_path,omitempty"`
+}
+
+// generateNotes asks GitHub for the automatically generated notes of pr,
+// using the release notes configuration at configPath in the repository.
+func (r *releaser) generateNotes(ctx context.Context, pr *pendingRelease, configPath string) (string, error) {
+	body := &generateNotesRequest{
+		TagName:               pr.tag,
+		TargetCommitish:       pr.commitSHA,
+		ConfigurationFilePath: configPath,
+	}
+	if prev := r.previousOf(ctx, pr); prev != nil {
+		body.PreviousTagName = prev.GetTagName()
+	}
+
+	req, err := r.client.NewRequest("POST", fmt.Sprintf("repos/%s/%s/releases/generate-notes", r.owner, r.repo), body)
+	if err != nil {
+		return "", err
+	}
+	notes := new(github.RepositoryReleaseNotes)
+	if _, err := r.client.Do(ctx, req, notes); err != nil {
+		return "", fmt.Errorf("failed to generate release notes: %w", err)
+	}
+	return notes.Body, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// verifyWithGeodeCLI runs the geode CLI's package verification against pkg.
+// args is split on whitespace and "{file}" is replaced by the path of the
+// package. If the CLI is not installed the check is skipped with a warning.
+func verifyWithGeodeCLI(ctx context.Context, pkg *geodePackage, args string) error {
+	geode, err := exec.LookPath("geode")
+	if err != nil {
+		slog.Warn("geode CLI not found in PATH, skipping package verification")
+		return nil
+	}
+
+	dir, err := os.MkdirTemp("", "gwtutil-verify-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir for verification: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, pkg.filename)
+	if err := os.WriteFile(path, pkg.data, 0o644); err != nil {
+		return fmt.Errorf("failed to write package for verification: %w", err)
+	}
+
+	fields := strings.Fields(args)
+	for i, f := range fields {
+		fields[i] = strings.ReplaceAll(f, "{file}", path)
+	}
+
+	slog.Debug("Running geode CLI verification", "cmd", geode, "args", fields)
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, geode, fields...)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("geode %s failed: %w\n%s", strings.Join(fields, " "), err, strings.TrimSpace(out.String()))
+	}
+
+	slog.Info("geode CLI verification passed", "file", pkg.filename)
+	return nil
+}
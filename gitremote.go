@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// githubRemote matches the HTTPS, SSH and scp-style URLs of a GitHub remote.
+var githubRemote = regexp.MustCompile(`^(?:https?://(?:[^@/]+@)?|ssh://git@|git@)github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// gitDefaults derives owner, repo and branch from the clone in the working
+// directory: the origin remote and the checked-out branch.
+func gitDefaults() map[string]string {
+	defaults := make(map[string]string)
+
+	if url, err := gitOutput("remote", "get-url", "origin"); err == nil {
+		if owner, repo, ok := parseGitHubRemote(url); ok {
+			defaults["owner"] = owner
+			defaults["repo"] = repo
+		}
+	}
+
+	// A detached HEAD has no branch to release from.
+	if branch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD"); err == nil && branch != "HEAD" {
+		defaults["branch"] = branch
+	}
+	return defaults
+}
+
+func parseGitHubRemote(url string) (owner, repo string, ok bool) {
+	m := githubRemote.FindStringSubmatch(strings.TrimSpace(url))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+func gitOutput(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// graphQL runs a GraphQL query through the authenticated client and decodes
+// its data into out. GraphQL reports errors in the body of a 200 response,
+// so those are surfaced here as well.
+func graphQL(ctx context.Context, client *github.Client, query string, vars map[string]any, out any) error {
+	return graphQLPreview(ctx, client, "", query, vars, out)
+}
+
+// graphQLPreview is graphQL with the schema preview of that name enabled,
+// for mutations GitHub still offers only as a preview.
+func graphQLPreview(ctx context.Context, client *github.Client, preview, query string, vars map[string]any, out any) error {
+	req, err := client.NewRequest("POST", "graphql", map[string]any{
+		"query":     query,
+		"variables": vars,
+	})
+	if err != nil {
+		return err
+	}
+	if preview != "" {
+		req.Header.Set("Accept", "application/vnd.github."+preview+"+json")
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return errors.New("graphql: " + strings.Join(msgs, "; "))
+	}
+	return json.Unmarshal(resp.Data, out)
+}
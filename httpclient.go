@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// httpConfig holds the HTTP client settings.
+type httpConfig struct {
+	caCert                string
+	timeout               time.Duration
+	dialTimeout           time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	idleConnTimeout       time.Duration
+	maxIdleConns          int
+	maxIdleConnsPerHost   int
+	tlsMinVersion         string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newHTTPClient builds the HTTP client shared by the GitHub API client and
+// raw artifact transfers. Proxies are taken from HTTPS_PROXY/HTTP_PROXY and
+// NO_PROXY; cfg.caCert, if set, adds a PEM bundle to the system roots for
+// runners behind TLS-intercepting proxies.
+func newHTTPClient(cfg *httpConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.DialContext = (&net.Dialer{Timeout: cfg.dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = cfg.tlsHandshakeTimeout
+	transport.ResponseHeaderTimeout = cfg.responseHeaderTimeout
+	transport.IdleConnTimeout = cfg.idleConnTimeout
+	transport.MaxIdleConns = cfg.maxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.maxIdleConnsPerHost
+
+	tlsConfig := &tls.Config{}
+	if cfg.tlsMinVersion != "" {
+		v, ok := tlsVersions[cfg.tlsMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version %q (want 1.2 or 1.3)", cfg.tlsMinVersion)
+		}
+		tlsConfig.MinVersion = v
+	}
+	if cfg.caCert != "" {
+		pem, err := os.ReadFile(cfg.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.caCert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: traceTransport{transport}, Timeout: cfg.timeout}, nil
+}
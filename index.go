@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultIndexURL = "https://api.geode-sdk.org"
+
+// indexClient talks to the Geode mods index API.
+type indexClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// indexError is an error response from the index API.
+type indexError struct {
+	status  int
+	message string
+}
+
+func (e *indexError) Error() string {
+	return fmt.Sprintf("index returned %d: %s", e.status, e.message)
+}
+
+// publish submits the package at downloadURL to the index, as a new version
+// of modID or, if the index does not know the mod yet, as a new mod.
+func (c *indexClient) publish(ctx context.Context, modID, downloadURL string) error {
+	body := map[string]string{"download_link": downloadURL}
+
+	err := c.post(ctx, "/v1/mods/"+url.PathEscape(modID)+"/versions", body)
+	var ie *indexError
+	if errors.As(err, &ie) && ie.status == http.StatusNotFound {
+		slog.Info("Mod not on the Geode index yet, submitting it", "mod_id", modID)
+		err = c.post(ctx, "/v1/mods", body)
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("Submitted version to the Geode index", "mod_id", modID, "download_url", downloadURL)
+	return nil
+}
+
+func (c *indexClient) post(ctx context.Context, path string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.baseURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	return readIndexError(resp)
+}
+
+// get decodes the payload of the index response to GET path into v.
+func (c *indexClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.baseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return readIndexError(resp)
+	}
+	var body struct {
+		Payload json.RawMessage `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode index response: %w", err)
+	}
+	return json.Unmarshal(body.Payload, v)
+}
+
+func readIndexError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	msg := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &apiErr) == nil && apiErr.Error != "" {
+		msg = apiErr.Error
+	}
+	return &indexError{status: resp.StatusCode, message: msg}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultBuildWorkflowName = "Build Geode Mod"
+
+// releaseWorkflowTemplate runs the tool after every successful build on the
+// release branch. It is triggered by workflow_run, so the source run, owner
+// and repository all come from the event.
+const releaseWorkflowTemplate = `name: Release
+
+on:
+  workflow_run:
+    workflows: [%q]
+    types: [completed]
+    branches: [%q]
+
+permissions:
+  contents: write
+  actions: read
+
+jobs:
+  release:
+    if: ${{ github.event.workflow_run.conclusion == 'success' }}
+    runs-on: ubuntu-latest
+    steps:
+      # The repository itself is only needed for .gwtreleaser.json.
+      - uses: actions/checkout@v4
+
+      - uses: actions/checkout@v4
+        with:
+          repository: TheBearodactyl/gwtreleaser
+          path: gwtreleaser
+
+      - uses: actions/setup-go@v5
+        with:
+          go-version-file: gwtreleaser/go.mod
+
+      - name: Build gwtreleaser
+        working-directory: gwtreleaser
+        run: go build -o "$RUNNER_TEMP/gwtutil" .
+
+      - name: Release
+        run: $RUNNER_TEMP/gwtutil -no-progress
+        env:
+          GITHUB_TOKEN: ${{ secrets.GITHUB_TOKEN }}
+`
+
+// initMain implements "init": it scaffolds a release workflow wired to the
+// repository's build workflow, plus a starter config file.
+func initMain(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	dir := fs.String("dir", ".", "Repository root to write the files into")
+	buildWorkflow := fs.String("workflow", "multi-platform.yml", "Filename of the build workflow to release from")
+	branch := fs.String("branch", "", "Branch to release from (default: the current branch, or main)")
+	force := fs.Bool("force", false, "Overwrite existing files")
+	fs.Parse(args)
+
+	if *branch == "" {
+		*branch = "main"
+		if b, err := gitOutput("-C", *dir, "rev-parse", "--abbrev-ref", "HEAD"); err == nil && b != "HEAD" {
+			*branch = b
+		}
+	}
+
+	workflowsDir := filepath.Join(*dir, ".github", "workflows")
+	buildName, err := workflowName(filepath.Join(workflowsDir, *buildWorkflow))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read %s (%v); assuming it is named %q\n", *buildWorkflow, err, defaultBuildWorkflowName)
+		buildName = defaultBuildWorkflowName
+	}
+
+	config, err := json.MarshalIndent(map[string]any{
+		"workflow":   *buildWorkflow,
+		"tag-prefix": "v",
+	}, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{filepath.Join(workflowsDir, "release.yml"), fmt.Sprintf(releaseWorkflowTemplate, buildName, *branch)},
+		{filepath.Join(*dir, defaultConfigFile), string(config) + "\n"},
+	}
+	for _, f := range files {
+		if err := writeNewFile(f.path, f.content, *force); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitFailure
+		}
+		fmt.Printf("Wrote %s\n", f.path)
+	}
+	return 0
+}
+
+// workflowName returns the top-level name of a workflow file, which is how
+// workflow_run triggers refer to it.
+func workflowName(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if name, ok := strings.CutPrefix(sc.Text(), "name:"); ok {
+			return strings.Trim(strings.TrimSpace(name), `"'`), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("workflow has no name")
+}
+
+func writeNewFile(path, content string, overwrite bool) error {
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0o644)
+}
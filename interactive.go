@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+const interactiveRunCount = 10
+
+// errCancelled is returned when the release is declined at a prompt.
+var errCancelled = errors.New("release cancelled")
+
+// prompter asks questions on the terminal for -interactive runs.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter() *prompter {
+	return &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+}
+
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// choose lists items and returns the index of the one picked, def if the
+// answer is empty.
+func (p *prompter) choose(label string, items []string, def int) (int, error) {
+	fmt.Fprintf(p.out, "\n%s:\n", label)
+	for i, item := range items {
+		fmt.Fprintf(p.out, "  %2d) %s\n", i+1, item)
+	}
+	for {
+		fmt.Fprintf(p.out, "Select [%d]: ", def+1)
+		answer, err := p.readLine()
+		if err != nil {
+			return 0, err
+		}
+		if answer == "" {
+			return def, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(items) {
+			return n - 1, nil
+		}
+		fmt.Fprintf(p.out, "Enter a number between 1 and %d.\n", len(items))
+	}
+}
+
+func (p *prompter) confirm(question string) (bool, error) {
+	fmt.Fprintf(p.out, "%s [y/N]: ", question)
+	answer, err := p.readLine()
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// pickRun lets the user choose one of the recent successful runs of the
+// workflow on the branch.
+func (r *releaser) pickRun(ctx context.Context, p *prompter) (*github.WorkflowRun, error) {
+	filter := r.opts.runFilter("success")
+	filter.PerPage = interactiveRunCount
+	workflowFile, runs, err := listRuns(ctx, r.client, r.artifactOwner, r.artifactRepo, r.opts.workflowFiles, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, withExitCode(exitNoRuns, fmt.Errorf("no successful workflow runs found for workflow '%s' on branch '%s'%s", r.opts.workflowFile, r.opts.branch, filterSuffix(filter)))
+	}
+
+	items := make([]string, len(runs))
+	for i, run := range runs {
+		title, _, _ := strings.Cut(run.GetHeadCommit().GetMessage(), "\n")
+		items[i] = fmt.Sprintf("#%d  %.7s  %s  %s", run.GetRunNumber(), run.GetHeadSHA(), run.GetCreatedAt().Format("2006-01-02 15:04"), title)
+	}
+	i, err := p.choose("Recent successful runs of "+workflowFile, items, 0)
+	if err != nil {
+		return nil, err
+	}
+	return runs[i], nil
+}
+
+// pickArtifact lets the user choose the artifact of run to release and
+// stores its name in the options.
+func (r *releaser) pickArtifact(ctx context.Context, p *prompter, run *github.WorkflowRun) error {
+	arts, _, err := r.client.Actions.ListWorkflowRunArtifacts(ctx, r.artifactOwner, r.artifactRepo, run.GetID(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	if len(arts.Artifacts) == 0 {
+		return withExitCode(exitArtifactNotFound, fmt.Errorf("run %d has no artifacts", run.GetID()))
+	}
+
+	def := 0
+	items := make([]string, len(arts.Artifacts))
+	for i, a := range arts.Artifacts {
+		items[i] = fmt.Sprintf("%s (%s)", a.GetName(), formatBytes(a.GetSizeInBytes()))
+		if a.GetName() == r.opts.artifactName {
+			def = i
+		}
+	}
+	i, err := p.choose(fmt.Sprintf("Artifacts of run #%d", run.GetRunNumber()), items, def)
+	if err != nil {
+		return err
+	}
+	r.opts.artifactName = arts.Artifacts[i].GetName()
+	return nil
+}
+
+// confirmRelease shows the mod.json and changelog section of each package
+// and asks whether to go ahead with tagging and releasing them.
+func confirmRelease(p *prompter, pkgs []*geodePackage) error {
+	for _, pkg := range pkgs {
+		fmt.Fprintf(p.out, "\n== %s (%s %s) ==\n", pkg.filename, pkg.mod.ID, pkg.mod.Version)
+		if raw, err := readPackageFile(pkg, "mod.json"); err == nil {
+			fmt.Fprintf(p.out, "\nmod.json:\n%s\n", strings.TrimSpace(string(raw)))
+		}
+		if changelog, err := readPackageFile(pkg, "changelog.md"); err == nil {
+			if excerpt := changelogExcerpt(string(changelog), pkg.mod.Version, changelogExcerptLimit); excerpt != "" {
+				fmt.Fprintf(p.out, "\nChangelog:\n%s\n", excerpt)
+			}
+		}
+	}
+
+	ok, err := p.confirm("\nCreate the tag and release?")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errCancelled
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// listedRun is a workflow run shown by "list".
+type listedRun struct {
+	RunID      int64         `json:"run_id"`
+	RunNumber  int           `json:"run_number"`
+	Conclusion string        `json:"conclusion"`
+	HeadSHA    string        `json:"head_sha"`
+	CreatedAt  string        `json:"created_at"`
+	Artifacts  []listedAsset `json:"artifacts"`
+	Released   []string      `json:"released,omitempty"`
+}
+
+type listedAsset struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// listMain implements "list": it shows the recent completed runs of the
+// workflow with their artifacts and whether they were released, to help
+// pick a -run-id.
+func listMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	opts.register(fs)
+	count := fs.Int("n", 10, "Number of runs to list")
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	var runs []*listedRun
+	if err == nil {
+		runs, err = r.listRuns(ctx, *count)
+	}
+	if err == nil {
+		err = writeRunList(os.Stdout, opts.outputFormat, runs)
+	}
+	if err != nil {
+		code := exitCodeOf(err)
+		slog.Error("List failed", "error", err, "exit_code", code)
+		return code
+	}
+	return 0
+}
+
+// listRuns returns the latest count completed runs. A run counts as released
+// if the state file says so or a tag points at its head commit.
+func (r *releaser) listRuns(ctx context.Context, count int) ([]*listedRun, error) {
+	filter := r.opts.runFilter("completed")
+	filter.PerPage = count
+	_, runs, err := listRuns(ctx, r.client, r.artifactOwner, r.artifactRepo, r.opts.workflowFiles, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, _, err := r.client.Repositories.ListTags(ctx, r.owner, r.repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	tagsAt := make(map[string][]string)
+	for _, t := range tags {
+		tagsAt[t.GetCommit().GetSHA()] = append(tagsAt[t.GetCommit().GetSHA()], t.GetName())
+	}
+	st := new(releaseState)
+	if r.opts.stateFile != "" {
+		if st, err = loadReleaseState(r.opts.stateFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var listed []*listedRun
+	for _, run := range runs {
+		lr := &listedRun{
+			RunID:      run.GetID(),
+			RunNumber:  run.GetRunNumber(),
+			Conclusion: run.GetConclusion(),
+			HeadSHA:    run.GetHeadSHA(),
+			CreatedAt:  run.GetCreatedAt().Format("2006-01-02 15:04"),
+			Released:   tagsAt[run.GetHeadSHA()],
+		}
+		if len(lr.Released) == 0 && st.released(run.GetID()) {
+			lr.Released = []string{"yes"}
+		}
+
+		arts, _, err := r.client.Actions.ListWorkflowRunArtifacts(ctx, r.artifactOwner, r.artifactRepo, run.GetID(), &github.ListOptions{PerPage: 100})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifacts of run %d: %w", run.GetID(), err)
+		}
+		for _, a := range arts.Artifacts {
+			lr.Artifacts = append(lr.Artifacts, listedAsset{Name: a.GetName(), Size: a.GetSizeInBytes()})
+		}
+		listed = append(listed, lr)
+	}
+	return listed, nil
+}
+
+func writeRunList(w io.Writer, format string, runs []*listedRun) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(runs)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\t#\tCONCLUSION\tCOMMIT\tCREATED\tARTIFACTS\tRELEASED")
+	for _, run := range runs {
+		var arts []string
+		for _, a := range run.Artifacts {
+			arts = append(arts, fmt.Sprintf("%s (%s)", a.Name, formatBytes(a.Size)))
+		}
+		released := strings.Join(run.Released, ", ")
+		if released == "" {
+			released = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%.7s\t%s\t%s\t%s\n", run.RunID, run.RunNumber, run.Conclusion, run.HeadSHA, run.CreatedAt, strings.Join(arts, ", "), released)
+	}
+	return tw.Flush()
+}
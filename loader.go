@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// targetLoader resolves -target-loader: "latest" is looked up on the index,
+// anything else is used as given.
+func (r *releaser) targetLoader(ctx context.Context) (string, error) {
+	target := r.opts.targetLoader
+	if target != "latest" {
+		return target, nil
+	}
+
+	var payload struct {
+		Tag string `json:"tag"`
+	}
+	index := &indexClient{baseURL: r.opts.indexURL, http: r.http}
+	if err := index.get(ctx, "/v1/loader/versions/latest", &payload); err != nil {
+		return "", fmt.Errorf("failed to look up the latest Geode loader: %w", err)
+	}
+	slog.Debug("Latest Geode loader on the index", "version", payload.Tag)
+	return payload.Tag, nil
+}
+
+// checkLoaderVersion compares the loader version pkg's mod.json targets
+// against minimum, failing if it is older, and against target, failing if
+// it is newer and so not released yet. Either bound may be empty.
+// Mismatches fail the check unless warnOnly is set.
+func checkLoaderVersion(pkg *geodePackage, minimum, target string, warnOnly bool) error {
+	geode, err := normalizeVersion(pkg.mod.Geode)
+	if err != nil {
+		return fmt.Errorf("%s: invalid geode version in mod.json: %w", pkg.filename, err)
+	}
+
+	var problems []string
+	if minimum != "" {
+		m, err := normalizeVersion(minimum)
+		if err != nil {
+			return fmt.Errorf("invalid minimum loader version: %w", err)
+		}
+		if compareVersions(geode, m) < 0 {
+			problems = append(problems, fmt.Sprintf("targets Geode %s, older than the minimum %s", geode, m))
+		}
+	}
+	if target != "" {
+		t, err := normalizeVersion(target)
+		if err != nil {
+			return fmt.Errorf("invalid target loader version: %w", err)
+		}
+		if compareVersions(geode, t) > 0 {
+			problems = append(problems, fmt.Sprintf("targets Geode %s, newer than the released %s", geode, t))
+		}
+	}
+	if len(problems) == 0 {
+		slog.Debug("Loader version is compatible", "file", pkg.filename, "geode", geode)
+		return nil
+	}
+
+	if warnOnly {
+		slog.Warn("Package targets an incompatible loader", "file", pkg.filename, "problems", problems)
+		return nil
+	}
+	return fmt.Errorf("%s %s", pkg.filename, strings.Join(problems, " and "))
+}
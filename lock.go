@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+const (
+	lockRefPrefix    = "refs/gwtreleaser-lock/"
+	lockPollInterval = 5 * time.Second
+	lockStampPrefix  = "locked at "
+)
+
+// acquireLock takes the repository-side lock for releasing tag, so that two
+// invocations releasing the same version cannot both create it. The lock is
+// a ref, which GitHub creates atomically, pointing at a tag object that
+// records when it was taken; a lock older than -lock-timeout is considered
+// abandoned, deleted if it has not changed since it was read, and taken
+// anew. The returned function releases the lock.
+func (r *releaser) acquireLock(ctx context.Context, tag, sha string) (func(), error) {
+	ref := lockRefPrefix + tag
+	var obj string
+	var stampedAt time.Time
+	for {
+		// The lock object is reused between attempts and only replaced once
+		// its timestamp is old enough to make the lock look abandoned soon.
+		if obj == "" || time.Since(stampedAt) > r.opts.lockTimeout/2 {
+			stampedAt = time.Now().UTC()
+			var err error
+			obj, err = createTagObject(ctx, r.client, r.owner, r.repo, "gwtreleaser-lock", lockStampPrefix+stampedAt.Format(time.RFC3339), sha)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create lock: %w", err)
+			}
+		}
+
+		lock := &github.Reference{Ref: github.String(ref), Object: &github.GitObject{SHA: github.String(obj)}}
+		_, _, err := r.client.Git.CreateRef(ctx, r.owner, r.repo, lock)
+		if err == nil {
+			slog.Debug("Acquired release lock", "ref", ref)
+			return func() { r.releaseLock(ref) }, nil
+		}
+		if !isStatus(err, http.StatusUnprocessableEntity) {
+			return nil, fmt.Errorf("failed to create lock ref %s: %w", ref, err)
+		}
+
+		held, age, err := r.lockAge(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		if held == "" {
+			continue
+		}
+		if age > r.opts.lockTimeout {
+			slog.Warn("Removing abandoned release lock", "ref", ref, "age", age.Round(time.Second))
+			if err := r.deleteRefIf(ctx, ref, held); err != nil {
+				return nil, fmt.Errorf("failed to remove abandoned lock ref %s: %w", ref, err)
+			}
+			continue
+		}
+
+		slog.Info("Another run is releasing this version, waiting", "tag", tag, "lock_age", age.Round(time.Second))
+		if err := sleepContext(ctx, lockPollInterval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lockAge returns the object the lock at ref points to and how long ago it
+// was taken. A lock released in the meantime has no object.
+func (r *releaser) lockAge(ctx context.Context, ref string) (string, time.Duration, error) {
+	cur, _, err := r.client.Git.GetRef(ctx, r.owner, r.repo, ref)
+	if isStatus(err, http.StatusNotFound) {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read lock ref %s: %w", ref, err)
+	}
+	held := cur.GetObject().GetSHA()
+	tag, _, err := r.client.Git.GetTag(ctx, r.owner, r.repo, held)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read lock %s: %w", ref, err)
+	}
+	stamp, ok := strings.CutPrefix(strings.TrimSpace(tag.GetMessage()), lockStampPrefix)
+	if !ok {
+		return "", 0, errors.New("lock " + ref + " was not created by gwtreleaser")
+	}
+	at, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		return "", 0, fmt.Errorf("lock %s has an invalid timestamp: %w", ref, err)
+	}
+	return held, time.Since(at), nil
+}
+
+const updateRefsMutation = `mutation($repositoryId: ID!, $name: GitRefname!, $before: GitObjectID!) {
+  updateRefs(input: {repositoryId: $repositoryId, refUpdates: [{name: $name, beforeOid: $before, afterOid: "0000000000000000000000000000000000000000", force: true}]}) {
+    clientMutationId
+  }
+}`
+
+// deleteRefIf deletes ref only if it still points to sha, so that of two
+// runs removing the same abandoned lock, the second cannot delete the lock
+// the first has taken since. The REST API has no conditional delete, so
+// this uses the GraphQL updateRefs mutation. A ref that moved is left alone
+// without an error.
+func (r *releaser) deleteRefIf(ctx context.Context, ref, sha string) error {
+	repo, _, err := r.client.Repositories.Get(ctx, r.owner, r.repo)
+	if err != nil {
+		return fmt.Errorf("failed to look up repository: %w", err)
+	}
+	var data struct{}
+	err = graphQLPreview(ctx, r.client, "update-refs-preview", updateRefsMutation, map[string]any{
+		"repositoryId": repo.GetNodeID(),
+		"name":         ref,
+		"before":       sha,
+	}, &data)
+	if err != nil {
+		if held, _, herr := r.lockAge(ctx, ref); herr == nil && held != sha {
+			slog.Debug("Lock changed before it could be removed", "ref", ref)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseLock deletes the lock ref. It uses its own context so the lock is
+// released even when the run was cancelled.
+func (r *releaser) releaseLock(ref string) {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+	if _, err := r.client.Git.DeleteRef(ctx, r.owner, r.repo, ref); err != nil && !isStatus(err, http.StatusNotFound) {
+		slog.Warn("Failed to release lock", "ref", ref, "error", err)
+		return
+	}
+	slog.Debug("Released release lock", "ref", ref)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// levelTrace sits below debug and additionally logs every HTTP exchange.
+const levelTrace = slog.LevelDebug - 4
+
+// logLevel maps the -v/-vv verbosity count onto a slog level.
+func logLevel(verbosity int) slog.Level {
+	switch {
+	case verbosity >= 2:
+		return levelTrace
+	case verbosity == 1:
+		return slog.LevelDebug
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// setupLogger installs the default slog logger, writing to stderr in the
+// requested format so stdout stays free for command output.
+func setupLogger(format string, level slog.Level) error {
+	handlerOpts := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.LevelKey && len(groups) == 0 && a.Value.Any() == levelTrace {
+				a.Value = slog.StringValue("TRACE")
+			}
+			return a
+		},
+	}
+
+	var handler slog.Handler
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, handlerOpts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, handlerOpts)
+	default:
+		return fmt.Errorf("unknown log format %q (want text or json)", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// logBuffer holds the records logged before setupLogger has run, such as
+// those of reading the config file, which may itself set -v or -log-format.
+type logBuffer struct {
+	records []slog.Record
+}
+
+func (b *logBuffer) Enabled(context.Context, slog.Level) bool { return true }
+
+func (b *logBuffer) Handle(_ context.Context, r slog.Record) error {
+	b.records = append(b.records, r.Clone())
+	return nil
+}
+
+func (b *logBuffer) WithAttrs([]slog.Attr) slog.Handler { return b }
+func (b *logBuffer) WithGroup(string) slog.Handler      { return b }
+
+// bufferLogs holds back log records until the returned function is called,
+// which replays them through the logger installed by then, or the previous
+// one if none was. Calling it again does nothing.
+func bufferLogs() func() {
+	prev := slog.Default()
+	buf := new(logBuffer)
+	logger := slog.New(buf)
+	slog.SetDefault(logger)
+	done := false
+	return func() {
+		if done {
+			return
+		}
+		done = true
+		if slog.Default() == logger {
+			slog.SetDefault(prev)
+		}
+		ctx := context.Background()
+		h := slog.Default().Handler()
+		for _, r := range buf.records {
+			if h.Enabled(ctx, r.Level) {
+				h.Handle(ctx, r)
+			}
+		}
+	}
+}
+
+// traceTransport logs each HTTP request and its outcome at trace level.
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !slog.Default().Enabled(ctx, levelTrace) {
+		return t.base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	slog.Log(ctx, levelTrace, "HTTP request", "method", req.Method, "url", traceURL(req), "range", req.Header.Get("Range"))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		slog.Log(ctx, levelTrace, "HTTP request failed", "method", req.Method, "url", traceURL(req), "error", err, "duration", time.Since(start))
+		return nil, err
+	}
+	slog.Log(ctx, levelTrace, "HTTP response",
+		"method", req.Method,
+		"url", traceURL(req),
+		"status", resp.StatusCode,
+		"content_length", resp.ContentLength,
+		"request_id", resp.Header.Get("X-GitHub-Request-Id"),
+		"duration", time.Since(start),
+	)
+	return resp, nil
+}
+
+type secretPathKey struct{}
+
+// withSecretPath marks requests made with ctx as having a credential in
+// their URL path, as webhook URLs do, so tracing leaves the path out.
+func withSecretPath(ctx context.Context) context.Context {
+	return context.WithValue(ctx, secretPathKey{}, true)
+}
+
+// traceURL returns the URL of req for logging. The userinfo and query are
+// dropped since they carry credentials and download signatures, and so is
+// the path of requests marked by withSecretPath.
+func traceURL(req *http.Request) string {
+	path := req.URL.EscapedPath()
+	if secret, _ := req.Context().Value(secretPathKey{}).(bool); secret {
+		path = "/…"
+	}
+	return req.URL.Scheme + "://" + req.URL.Host + path
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/oauth2"
+	oauthgithub "golang.org/x/oauth2/github"
+)
+
+// loginMain implements "login": it authorizes an OAuth app through GitHub's
+// device flow and stores the resulting token for later runs.
+func loginMain(args []string) int {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	clientID := fs.String("client-id", os.Getenv("GWTRELEASER_CLIENT_ID"), "Client ID of the OAuth app to authorize, with device flow enabled (default $GWTRELEASER_CLIENT_ID)")
+	scopes := fs.String("scopes", "repo", "Comma-separated OAuth scopes to request")
+	fs.Parse(args)
+
+	if *clientID == "" {
+		fmt.Fprintln(os.Stderr, "-client-id or GWTRELEASER_CLIENT_ID is required")
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(0)
+	defer stop()
+
+	conf := &oauth2.Config{
+		ClientID: *clientID,
+		Endpoint: oauthgithub.Endpoint,
+		Scopes:   strings.Split(*scopes, ","),
+	}
+	token, err := deviceLogin(ctx, conf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	}
+
+	where, err := saveStoredToken(token.AccessToken)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	fmt.Fprintf(os.Stderr, "Logged in; token stored in %s\n", where)
+	return 0
+}
+
+// deviceLogin shows the user code to enter on GitHub and waits until the
+// user has authorized it.
+func deviceLogin(ctx context.Context, conf *oauth2.Config) (*oauth2.Token, error) {
+	auth, err := conf.DeviceAuth(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start device login: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Open %s and enter the code %s\n", auth.VerificationURI, auth.UserCode)
+
+	token, err := conf.DeviceAccessToken(ctx, auth)
+	if err != nil {
+		return nil, fmt.Errorf("device login failed: %w", err)
+	}
+	return token, nil
+}
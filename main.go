@@ -1,317 +1,565 @@
 package main
 
 import (
-	"archive/zip"
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"path/filepath"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/google/go-github/v55/github"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/oauth2"
 )
 
-type ModJSON struct {
-	Version string `json:"version"`
-}
-
-var verbose bool
-
-func debugf(format string, args ...any) {
-	if verbose {
-		fmt.Printf(format+"\n", args...)
-	}
+// commands are the subcommands available besides the default release run.
+var commands = map[string]func(args []string) int{
+	"apply":  applyMain,
+	"batch":  batchMain,
+	"init":   initMain,
+	"list":   listMain,
+	"login":  loginMain,
+	"plan":   planMain,
+	"scan":   scanMain,
+	"serve":  serveMain,
+	"status": statusMain,
+	"undo":   undoMain,
+	"verify": verifyMain,
+	"watch":  watchMain,
 }
 
 func main() {
-	owner := flag.String("owner", "", "GitHub repo owner (required)")
-	repo := flag.String("repo", "", "GitHub repo name (required)")
-	branch := flag.String("branch", "main", "Branch name to look for workflow runs")
-	workflowFile := flag.String("workflow", "multi-platform.yml", "Workflow filename")
-	flag.BoolVar(&verbose, "verbose", false, "Enable verbose debug output")
-	flag.Parse()
-
-	if *owner == "" || *repo == "" {
-		flag.Usage()
-		os.Exit(1)
-	}
+	var code int
+	if cmd, ok := commands[firstArg()]; ok {
+		code = cmd(os.Args[2:])
+	} else {
+		code = releaseMain(os.Args[1:])
+	}
+	shutdownTracing()
+	os.Exit(code)
+}
 
-	token := os.Getenv("GITHUB_TOKEN")
-	if token == "" {
-		log.Fatal("GITHUB_TOKEN environment variable must be set")
+func firstArg() string {
+	if len(os.Args) > 1 {
+		return os.Args[1]
 	}
+	return ""
+}
 
-	ctx := context.Background()
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
-
-	debugf("Listing workflow runs for workflow file %q on branch %q", *workflowFile, *branch)
-	runs, _, err := client.Actions.ListWorkflowRunsByFileName(ctx, *owner, *repo, *workflowFile, &github.ListWorkflowRunsOptions{
-		Status: "completed",
-		Branch: *branch,
-	})
-	if err != nil {
-		log.Fatalf("Error listing workflow runs: %v", err)
-	}
-	if len(runs.WorkflowRuns) == 0 {
-		log.Fatalf("No completed workflow runs found for workflow '%s' on branch '%s'", *workflowFile, *branch)
+const commandUsage = `
+Commands:
+  apply   release exactly what a file written by plan describes
+  batch   release every repository listed in a manifest file
+  init    write a release workflow and config file into a repository
+  list    show recent runs with their artifacts and whether they were released
+  login   authorize with GitHub in the browser and store the token
+  plan    work out a release without making it and write it as JSON
+  scan    release the unreleased builds of every repository in an organization
+  serve   release successful builds announced by workflow_run webhooks
+  status  report whether the latest successful build is released yet
+  undo    delete a release, its assets and its tag
+  verify  check that a release's assets match the build they came from
+  watch   keep polling the workflow and release every new successful build
+
+Run without a command to release the latest build.
+`
+
+// releaseMain performs a release run and returns the process exit code.
+func releaseMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of %s [command]:\n", os.Args[0])
+		fs.PrintDefaults()
+		fmt.Fprint(fs.Output(), commandUsage)
+		fmt.Fprint(fs.Output(), exitCodeUsage)
+	}
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, errMissingRepo) {
+			fs.Usage()
+		}
+		return exitUsage
 	}
 
-	debugf("Found %d completed workflow runs", len(runs.WorkflowRuns))
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
 
-	latestRun := runs.WorkflowRuns[0]
-	debugf("Latest run ID: %d, Head SHA: %s, Created at: %v", latestRun.GetID(), latestRun.GetHeadSHA(), latestRun.GetCreatedAt())
+	res, err := run(ctx, &opts)
+	return reportResult(opts.outputFormat, res, err)
+}
 
-	debugf("Listing artifacts for repo %s/%s", *owner, *repo)
-	arts, _, err := client.Actions.ListArtifacts(ctx, *owner, *repo, &github.ListOptions{})
+// reportResult prints the outcome of a release run and returns the process
+// exit code.
+func reportResult(format string, res *runResult, err error) int {
 	if err != nil {
-		log.Fatalf("Error listing artifacts: %v", err)
-	}
-	debugf("Found %d artifacts total", len(arts.Artifacts))
-
-	var artifact *github.Artifact
-	for _, a := range arts.Artifacts {
-		debugf("Artifact: ID=%d, Name=%q, WorkflowRunID=%d", a.GetID(), a.GetName(), *a.GetWorkflowRun().ID)
-		if a.GetName() == "Build Output" && *a.GetWorkflowRun().ID == latestRun.GetID() {
-			artifact = a
-			break
+		code := exitCodeOf(err)
+		slog.Error("Release failed", "error", err, "exit_code", code)
+		return code
+	}
+	if err := writeResult(os.Stdout, format, res); err != nil {
+		slog.Error("Failed to write result", "error", err)
+		return exitFailure
+	}
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		if err := writeActionOutputs(path, res); err != nil {
+			slog.Error("Failed to write step outputs", "error", err)
+			return exitFailure
 		}
 	}
-	if artifact == nil {
-		log.Fatalf("Artifact 'Build Output' not found for latest run")
-	}
-	debugf("Selected artifact ID: %d", artifact.GetID())
+	return 0
+}
 
-	debugf("Getting artifact download URL")
-	artifactURL, _, err := client.Actions.DownloadArtifact(ctx, *owner, *repo, artifact.GetID(), true)
-	if err != nil {
-		log.Fatalf("Error getting artifact download URL: %v", err)
+// signalContext returns a context cancelled on SIGINT or SIGTERM and, if
+// timeout is positive, once it elapses.
+func signalContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore default signal handling once cancelled so a second
+		// interrupt kills the process outright.
+		<-ctx.Done()
+		stop()
+	}()
+	if timeout <= 0 {
+		return ctx, stop
 	}
-	debugf("Downloading artifact from: %s", artifactURL.String())
 
-	tmpZipFile, err := os.CreateTemp("", "artifact-*.zip")
-	if err != nil {
-		log.Fatalf("Error creating temp file for artifact download: %v", err)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
 	}
-	defer func() {
-		tmpZipFile.Close()
-		os.Remove(tmpZipFile.Name())
-	}()
-
-	debugf("Downloading artifact to temp file: %s", tmpZipFile.Name())
+}
 
-	resp, err := http.Get(artifactURL.String())
-	if err != nil {
-		log.Fatalf("Error downloading artifact: %v", err)
-	}
-	defer resp.Body.Close()
+// releaser carries the clients and settings shared by the steps of a run.
+type releaser struct {
+	opts   *options
+	client *github.Client
+	http   *http.Client
+	index  *indexClient
+	owner  string
+	repo   string
+	rb     rollback
+
+	// artifactOwner and artifactRepo hold the workflow runs and artifacts,
+	// usually the same repository as owner and repo.
+	artifactOwner string
+	artifactRepo  string
+
+	// source is the workflow run being released, nil for local packages.
+	source *github.WorkflowRun
+
+	// audit records the run's mutating API requests with -audit-dir, and
+	// actor is who the token acts for, looked up on first use.
+	audit *auditLog
+	actor string
+
+	// planning makes run stop once it has worked out plan. A plan set
+	// without planning is the one the run must carry out.
+	planning bool
+	plan     *releasePlan
+}
 
-	written, err := io.Copy(tmpZipFile, resp.Body)
+// run performs a single release run with opts.
+func run(ctx context.Context, opts *options) (*runResult, error) {
+	r, err := newReleaser(ctx, opts)
 	if err != nil {
-		log.Fatalf("Error writing artifact to temp file: %v", err)
+		return nil, err
 	}
-	debugf("Downloaded %d bytes to %s", written, tmpZipFile.Name())
+	return r.run(ctx)
+}
 
-	zipData, err := os.ReadFile(tmpZipFile.Name())
+// newReleaser authenticates the API clients used by a run.
+func newReleaser(ctx context.Context, opts *options) (*releaser, error) {
+	token, err := githubToken()
 	if err != nil {
-		log.Fatalf("Error reading downloaded artifact zip from temp file: %v", err)
+		return nil, err
 	}
 
-	geodeData, geodeFilename, err := extractGeodeFileFromZip(zipData)
+	httpClient, err := newHTTPClient(&opts.http)
 	if err != nil {
-		log.Fatalf("Error extracting .geode file: %v", err)
+		return nil, err
 	}
-	fmt.Printf("Found .geode file: %s\n", geodeFilename)
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	tc := oauth2.NewClient(context.WithValue(ctx, oauth2.HTTPClient, httpClient), ts)
 
-	debugf("Listing contents of artifact zip:")
-	if verbose {
-		if err := debugListZipContents(zipData); err != nil {
-			debugf("Failed to list artifact zip contents: %v", err)
+	var index *indexClient
+	if opts.publishIndex {
+		indexToken := os.Getenv("GEODE_INDEX_TOKEN")
+		if indexToken == "" {
+			return nil, withExitCode(exitAuth, errors.New("GEODE_INDEX_TOKEN environment variable must be set to publish to the index"))
 		}
+		index = &indexClient{baseURL: opts.indexURL, token: indexToken, http: httpClient}
 	}
 
-	debugf("Listing contents of .geode zip:")
-	if verbose {
-		if err := debugListZipContents(geodeData); err != nil {
-			debugf("Failed to list .geode zip contents: %v", err)
+	var audit *auditLog
+	if opts.auditDir != "" {
+		audit = &auditLog{}
+		tc = &http.Client{Transport: auditTransport{tc.Transport, audit}}
+	}
+	client := github.NewClient(tc)
+	if opts.upstream {
+		if err := useUpstream(ctx, client, opts); err != nil {
+			return nil, err
 		}
 	}
 
-	version, err := parseVersionFromGeode(geodeData)
-	if err != nil {
-		log.Fatalf("Error parsing mod.json: %v", err)
-	}
-	fmt.Printf("Parsed version: %s\n", version)
+	return &releaser{
+		opts:   opts,
+		client: client,
+		http:   httpClient,
+		index:  index,
+		owner:  opts.owner,
+		repo:   opts.repo,
 
-	tagName := fmt.Sprintf(version)
+		artifactOwner: opts.artifactOwner,
+		artifactRepo:  opts.artifactRepo,
+		audit:         audit,
+	}, nil
+}
 
-	debugf("Getting branch ref 'refs/heads/%s'", *branch)
-	ref, _, err := client.Git.GetRef(ctx, *owner, *repo, "refs/heads/"+*branch)
-	if err != nil {
-		log.Fatalf("Error getting branch ref: %v", err)
-	}
-	commitSHA := ref.GetObject().GetSHA()
-	debugf("Latest commit SHA on branch %s: %s", *branch, commitSHA)
-
-	debugf("Creating git tag object %s", tagName)
-	tagMessage := fmt.Sprintf("Tag for version %s", version)
-	tag := &github.Tag{
-		Tag:     github.String(tagName),
-		Message: github.String(tagMessage),
-		Object: &github.GitObject{
-			Type: github.String("commit"),
-			SHA:  github.String(commitSHA),
-		},
-		Tagger: &github.CommitAuthor{
-			Name:  github.String("GitHub Actions Bot"),
-			Email: github.String("actions@github.com"),
-		},
-	}
-
-	createdTag, _, err := client.Git.CreateTag(ctx, *owner, *repo, tag)
-	if err != nil {
-		log.Fatalf("Error creating git tag object: %v", err)
+// run selects the workflow run to release, publishes its packages and sends
+// the notifications.
+func (r *releaser) run(ctx context.Context) (res *runResult, err error) {
+	ctx, span := startSpan(ctx, "release run", attribute.String("repository", r.owner+"/"+r.repo))
+	defer func() { endSpan(span, err) }()
+	opts := r.opts
+	r.rb = rollback{}
+	r.source = nil
+	var latestRun *github.WorkflowRun
+	var zipData []byte
+	var commitSHA string
+	if opts.checkRun && !r.planning {
+		defer func() { r.reportCheckRun(ctx, commitSHA, res, err) }()
+	}
+	if opts.commitStatus && !r.planning {
+		defer func() { r.reportCommitStatus(ctx, commitSHA, res, err) }()
+	}
+	if opts.pushgateway != "" && !r.planning {
+		start := time.Now()
+		defer func() { r.pushMetrics(ctx, &runMetrics{start: start, downloaded: len(zipData), res: res, err: err}) }()
+	}
+	if r.audit != nil {
+		r.audit.reset()
+		defer func() { r.writeAudit(ctx, latestRun.GetID(), res, err) }()
 	}
-	debugf("Created tag object SHA: %s", createdTag.GetSHA())
+	defer func() {
+		if err != nil {
+			if ctx.Err() != nil {
+				r.rb.run()
+			}
+			if !errors.Is(err, errCancelled) && !r.planning {
+				r.notifyFailure(latestRun.GetID(), err)
+			}
+		}
+	}()
 
-	refTag := &github.Reference{
-		Ref: github.String("refs/tags/" + tagName),
-		Object: &github.GitObject{
-			SHA: createdTag.SHA,
-		},
+	if !opts.noPreflight {
+		if err := r.preflight(ctx); err != nil {
+			return nil, err
+		}
 	}
 
-	_, _, err = client.Git.CreateRef(ctx, *owner, *repo, refTag)
-	if err != nil {
-		log.Fatalf("Error creating tag ref: %v", err)
+	var p *prompter
+	if opts.interactive {
+		p = newPrompter()
 	}
-	fmt.Printf("Created tag %s\n", tagName)
 
-	debugf("Creating release for tag %s", tagName)
-	release := &github.RepositoryRelease{
-		TagName: github.String(tagName),
-		Name:    github.String(fmt.Sprintf("Release %s", tagName)),
+	var pkgs []*geodePackage
+	switch {
+	case opts.buildCmd != "":
+		var path string
+		if path, err = runBuild(ctx, opts.buildCmd, opts.buildDir); err == nil {
+			pkgs, err = localPackages(path)
+		}
+	case opts.file != "":
+		pkgs, err = localPackages(opts.file)
+	default:
+		latestRun, zipData, err = r.runArtifact(ctx, p)
+		if err == nil {
+			_, span := startSpan(ctx, "extract", attribute.Int("artifact.bytes", len(zipData)))
+			if pkgs, err = extractGeodePackages(zipData, opts.packageLayout, opts.mergePackages); err != nil {
+				err = fmt.Errorf("failed to extract .geode file: %w", err)
+			}
+			endSpan(span, err)
+		}
 	}
-	createdRelease, _, err := client.Repositories.CreateRelease(ctx, *owner, *repo, release)
 	if err != nil {
-		log.Fatalf("Error creating release: %v", err)
+		return nil, err
 	}
-	debugf("Created release ID: %d", createdRelease.GetID())
 
-	tmpfile, err := os.CreateTemp("", "mod-*.geode")
-	if err != nil {
-		log.Fatalf("Error creating temp file for upload: %v", err)
+	r.source = latestRun
+	if latestRun != nil && r.opts.stateFile != "" {
+		st, err := loadReleaseState(r.opts.stateFile)
+		if err != nil {
+			return nil, err
+		}
+		if st.released(latestRun.GetID()) {
+			slog.Info("Run is already released, nothing to do", "run_id", latestRun.GetID(), "state", r.opts.stateFile)
+			return &runResult{RunID: latestRun.GetID()}, nil
+		}
 	}
-	defer func() {
-		tmpfile.Close()
-		os.Remove(tmpfile.Name())
-	}()
 
-	_, err = tmpfile.Write(geodeData)
+	extras, err := collectExtraAssets(opts.extraAssets, zipData)
 	if err != nil {
-		log.Fatalf("Error writing .geode to temp file: %v", err)
+		return nil, err
 	}
-	debugf("Wrote .geode data to temp file %s", tmpfile.Name())
 
-	uploadOpts := &github.UploadOptions{
-		Name: geodeFilename,
+	if r.plan != nil && !r.planning {
+		commitSHA = r.plan.Commit
+	} else {
+		slog.Debug("Getting branch ref", "ref", "refs/heads/"+opts.branch)
+		ref, _, err := r.client.Git.GetRef(ctx, r.owner, r.repo, "refs/heads/"+opts.branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get branch ref: %w", err)
+		}
+		commitSHA = ref.GetObject().GetSHA()
+		slog.Debug("Resolved branch head", "branch", opts.branch, "sha", commitSHA)
 	}
 
-	f, err := os.Open(tmpfile.Name())
-	if err != nil {
-		log.Fatalf("Error opening temp file for upload: %v", err)
+	if latestRun != nil && !opts.crossRepo() && latestRun.GetHeadSHA() != commitSHA {
+		// The tag goes on the branch head, which was not what the build
+		// was made from.
+		if opts.strict {
+			return nil, fmt.Errorf("branch %s moved to %.7s since run %d built %.7s", opts.branch, commitSHA, latestRun.GetID(), latestRun.GetHeadSHA())
+		}
+		slog.Warn("Branch head differs from the built commit, tagging an untested commit", "branch", opts.branch, "head_sha", commitSHA, "run_sha", latestRun.GetHeadSHA())
 	}
-	defer f.Close()
 
-	debugf("Uploading release asset %s", geodeFilename)
-	_, _, err = client.Repositories.UploadReleaseAsset(ctx, *owner, *repo, createdRelease.GetID(), uploadOpts, f)
-	if err != nil {
-		log.Fatalf("Error uploading release asset: %v", err)
+	if opts.symbols {
+		shared, err := artifactSymbols(zipData)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			own, err := stripSymbols(pkg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to strip debug symbols from %s: %w", pkg.filename, err)
+			}
+			pkg.symbols = append(own, symbolsOf(pkg, shared, len(pkgs) > 1)...)
+		}
 	}
 
-	fmt.Println("Release created and asset uploaded successfully")
-}
+	// Stamping comes before the checks below so that the package they
+	// clear, and the malware scan in particular, is the one released.
+	if opts.stamp != "" {
+		var committedAt time.Time
+		if latestRun == nil {
+			commit, _, err := r.client.Git.GetCommit(ctx, r.owner, r.repo, commitSHA)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get commit %s: %w", commitSHA, err)
+			}
+			committedAt = commit.GetCommitter().GetDate().Time
+		}
+		info := newBuildInfo(r.artifactOwner, r.artifactRepo, commitSHA, committedAt, latestRun)
+		for _, pkg := range pkgs {
+			if err := stampPackage(pkg, info, opts.stamp); err != nil {
+				return nil, fmt.Errorf("failed to stamp %s: %w", pkg.filename, err)
+			}
+		}
+	}
 
-func extractGeodeFileFromZip(zipData []byte) ([]byte, string, error) {
-	r, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to open zip reader: %w", err)
+	for _, pkg := range pkgs {
+		if err := checkPlatforms(pkg, opts.platforms, opts.platformsWarn); err != nil {
+			return nil, err
+		}
 	}
 
-	for _, f := range r.File {
-		if strings.HasSuffix(f.Name, ".geode") {
-			rc, err := f.Open()
-			if err != nil {
-				return nil, "", fmt.Errorf("failed to open .geode file inside zip: %w", err)
+	if opts.geodeVerify {
+		for _, pkg := range pkgs {
+			if err := verifyWithGeodeCLI(ctx, pkg, opts.geodeVerifyArgs); err != nil {
+				return nil, err
 			}
-			defer rc.Close()
+		}
+	}
 
-			data, err := io.ReadAll(rc)
-			if err != nil {
-				return nil, "", fmt.Errorf("failed to read .geode file inside zip: %w", err)
+	if opts.minLoader != "" || opts.targetLoader != "" {
+		target, err := r.targetLoader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range pkgs {
+			if err := checkLoaderVersion(pkg, opts.minLoader, target, opts.loaderWarn); err != nil {
+				return nil, err
 			}
+		}
+	}
 
-			debugf("Extracted .geode file from zip: %s (%d bytes)", f.Name, len(data))
+	if opts.checkDependencies {
+		for _, pkg := range pkgs {
+			if err := r.checkDependencies(ctx, pkg); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-			return data, filepath.Base(f.Name), nil
+	if opts.malwareScan != "" {
+		for _, pkg := range pkgs {
+			if err := r.scanForMalware(ctx, pkg); err != nil {
+				return nil, err
+			}
 		}
 	}
 
-	return nil, "", fmt.Errorf(".geode file not found in zip")
-}
+	if opts.interactive {
+		if err := confirmRelease(p, pkgs); err != nil {
+			return nil, err
+		}
+	}
 
-func parseVersionFromGeode(geodeData []byte) (string, error) {
-	r, err := zip.NewReader(bytes.NewReader(geodeData), int64(len(geodeData)))
-	if err != nil {
-		return "", fmt.Errorf("failed to open .geode as zip: %w", err)
+	if opts.checkSourceVersion {
+		sourceSHA := commitSHA
+		if latestRun != nil && !opts.crossRepo() {
+			sourceSHA = latestRun.GetHeadSHA()
+		}
+		for _, pkg := range pkgs {
+			if err := r.checkSourceVersion(ctx, pkg, sourceSHA); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	for _, f := range r.File {
-		if f.FileInfo().IsDir() {
-			continue
+	targets := make([]*releaseTarget, len(pkgs))
+	for i, pkg := range pkgs {
+		if targets[i], err = r.targetOf(ctx, pkg, commitSHA, len(pkgs) > 1); err != nil {
+			if len(pkgs) > 1 {
+				err = fmt.Errorf("%s: %w", pkg.mod.ID, err)
+			}
+			return nil, err
 		}
+	}
 
-		if strings.HasSuffix(f.Name, "mod.json") {
-			rc, err := f.Open()
-			if err != nil {
-				return "", fmt.Errorf("failed to open mod.json inside .geode: %w", err)
+	pending := make([]*pendingRelease, len(pkgs))
+	for i, pkg := range pkgs {
+		if pending[i], err = r.pendingReleaseOf(ctx, pkg, targets[i], extras, commitSHA); err != nil {
+			if len(pkgs) > 1 {
+				err = fmt.Errorf("%s: %w", pkg.mod.ID, err)
 			}
-			defer rc.Close()
+			return nil, err
+		}
+	}
 
-			debugf("Found mod.json inside .geode at path: %s", f.Name)
+	if r.planning || r.plan != nil {
+		source := zipData
+		if source == nil {
+			source = pkgs[0].data
+		}
+		plan := r.buildPlan(latestRun, source, commitSHA, pending, targets)
+		if r.planning {
+			r.plan = plan
+			return &runResult{RunID: latestRun.GetID(), Commit: commitSHA}, nil
+		}
+		if err := checkPlan(r.plan, plan); err != nil {
+			return nil, err
+		}
+	}
 
-			var mod ModJSON
-			if err := json.NewDecoder(rc).Decode(&mod); err != nil {
-				return "", fmt.Errorf("failed to decode mod.json: %w", err)
-			}
+	if opts.deploymentEnvironment != "" {
+		deployment, derr := r.startDeployment(ctx, commitSHA)
+		if derr != nil {
+			return nil, derr
+		}
+		defer func() { r.finishDeployment(ctx, deployment, res, err) }()
+	}
 
-			if mod.Version == "" {
-				return "", fmt.Errorf("version key not found in mod.json")
+	res = &runResult{RunID: latestRun.GetID(), Commit: commitSHA}
+	var announcements []*announcement
+	for i, pkg := range pkgs {
+		release := r.releasePackage
+		if opts.nightly {
+			release = r.releaseNightly
+		}
+		rel, err := release(ctx, pending[i], targets[i])
+		if err != nil {
+			if len(pkgs) > 1 {
+				err = fmt.Errorf("%s: %w", pkg.mod.ID, err)
 			}
+			return nil, err
+		}
+		res.Releases = append(res.Releases, *rel)
+		announcements = append(announcements, newAnnouncement(r.owner+"/"+r.repo, pkg, rel))
+	}
 
-			return mod.Version, nil
+	if r.index != nil {
+		for _, rel := range res.Releases {
+			if err := r.index.publish(ctx, rel.ModID, rel.Assets[0].DownloadURL); err != nil {
+				return nil, fmt.Errorf("released %s but failed to publish it to the Geode index: %w", rel.Tag, err)
+			}
 		}
 	}
 
-	return "", fmt.Errorf("mod.json not found inside .geode file")
+	if latestRun != nil {
+		r.recordRun(latestRun.GetID(), res)
+	}
+	r.notify(ctx, latestRun.GetID(), announcements)
+	return res, nil
 }
 
-func debugListZipContents(zipData []byte) error {
-	r, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+// recordRun adds runID to the state file, if one is configured.
+func (r *releaser) recordRun(runID int64, res *runResult) {
+	if r.opts.stateFile == "" {
+		return
+	}
+	st, err := loadReleaseState(r.opts.stateFile)
+	if err == nil {
+		st.record(runID, res)
+		err = st.save(r.opts.stateFile)
+	}
+	if err != nil {
+		slog.Error("Failed to update state file", "path", r.opts.stateFile, "error", err)
+	}
+}
+
+// runArtifact selects the workflow run to release and returns it together
+// with its downloaded build artifact.
+func (r *releaser) runArtifact(ctx context.Context, p *prompter) (*github.WorkflowRun, []byte, error) {
+	run, err := r.discoverRun(ctx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if r.opts.interactive {
+		if err := r.pickArtifact(ctx, p, run); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	downloadCtx, span := startSpan(ctx, "download", attribute.Int64("run.id", run.GetID()), attribute.String("artifact", r.opts.artifactName))
+	zipData, err := r.downloadRunArtifact(downloadCtx, run)
+	span.SetAttributes(attribute.Int("artifact.bytes", len(zipData)))
+	endSpan(span, err)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	return run, zipData, nil
+}
 
-	for _, f := range r.File {
-		debugf("  %s", f.Name)
+// discoverRun selects the workflow run to release.
+func (r *releaser) discoverRun(ctx context.Context, p *prompter) (run *github.WorkflowRun, err error) {
+	ctx, span := startSpan(ctx, "discover run")
+	defer func() { endSpan(span, err) }()
+
+	opts := r.opts
+	switch {
+	case opts.interactive && opts.runID == 0:
+		return r.pickRun(ctx, p)
+	case opts.bump != "":
+		return bumpAndWait(ctx, r.client, r.owner, r.repo, opts)
+	case opts.runID != 0:
+		run, err = getRun(ctx, r.client, r.artifactOwner, r.artifactRepo, opts.runID)
+		if reason := opts.runMismatch(run); err == nil && reason != "" {
+			err = fmt.Errorf("workflow run %d does not match -event and -actor: %s", run.GetID(), reason)
+		}
+		return run, err
+	default:
+		return retryDiscovery(ctx, opts.discoveryWait, func() (*github.WorkflowRun, error) {
+			return findLatestRun(ctx, r.client, r.artifactOwner, r.artifactRepo, opts.workflowFiles, opts.runFilter("completed"))
+		})
 	}
-	return nil
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Malware scanners accepted by -malware-scan.
+const (
+	scanVirusTotal = "virustotal"
+	scanClamAV     = "clamav"
+)
+
+const virusTotalAPI = "https://www.virustotal.com/api/v3"
+
+// scanForMalware scans pkg with the -malware-scan scanner and fails with
+// exitMalware when more than -malware-threshold engines detect it, unless
+// -malware-warn is set.
+func (r *releaser) scanForMalware(ctx context.Context, pkg *geodePackage) error {
+	var detections int
+	var err error
+	switch r.opts.malwareScan {
+	case scanVirusTotal:
+		detections, err = r.virusTotalDetections(ctx, pkg)
+	case scanClamAV:
+		detections, err = clamAVDetections(ctx, pkg)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to scan %s for malware: %w", pkg.filename, err)
+	}
+
+	if detections <= r.opts.malwareThreshold {
+		slog.Info("Malware scan passed", "file", pkg.filename, "scanner", r.opts.malwareScan, "detections", detections)
+		return nil
+	}
+	if r.opts.malwareWarn {
+		slog.Warn("Malware scan flagged the package", "file", pkg.filename, "scanner", r.opts.malwareScan, "detections", detections, "threshold", r.opts.malwareThreshold)
+		return nil
+	}
+	return withExitCode(exitMalware, fmt.Errorf("%s: %s reported %d detections (threshold %d)", pkg.filename, r.opts.malwareScan, detections, r.opts.malwareThreshold))
+}
+
+// virusTotalStats is the analysis summary VirusTotal reports for a file.
+type virusTotalStats struct {
+	Malicious int `json:"malicious"`
+}
+
+// virusTotalDetections returns the number of engines flagging pkg as
+// malicious. A package VirusTotal already knows by digest is not uploaded
+// again; otherwise it is submitted and the analysis is polled until done.
+func (r *releaser) virusTotalDetections(ctx context.Context, pkg *geodePackage) (int, error) {
+	var report struct {
+		Data struct {
+			Attributes struct {
+				Stats virusTotalStats `json:"last_analysis_stats"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	err := r.virusTotalRequest(ctx, http.MethodGet, "/files/"+sha256Hex(pkg.data), nil, "", &report)
+	if err == nil {
+		slog.Debug("VirusTotal already knows the package", "file", pkg.filename)
+		return report.Data.Attributes.Stats.Malicious, nil
+	}
+	if !errors.Is(err, errVirusTotalNotFound) {
+		return 0, err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", pkg.filename)
+	if err != nil {
+		return 0, err
+	}
+	part.Write(pkg.data)
+	mw.Close()
+
+	var submitted struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := r.virusTotalRequest(ctx, http.MethodPost, "/files", &body, mw.FormDataContentType(), &submitted); err != nil {
+		return 0, err
+	}
+	slog.Info("Submitted package to VirusTotal, waiting for the analysis", "file", pkg.filename, "analysis_id", submitted.Data.ID)
+
+	for {
+		var analysis struct {
+			Data struct {
+				Attributes struct {
+					Status string          `json:"status"`
+					Stats  virusTotalStats `json:"stats"`
+				} `json:"attributes"`
+			} `json:"data"`
+		}
+		if err := r.virusTotalRequest(ctx, http.MethodGet, "/analyses/"+submitted.Data.ID, nil, "", &analysis); err != nil {
+			return 0, err
+		}
+		if a := analysis.Data.Attributes; a.Status == "completed" {
+			return a.Stats.Malicious, nil
+		}
+		if err := sleepContext(ctx, 15*time.Second); err != nil {
+			return 0, err
+		}
+	}
+}
+
+var errVirusTotalNotFound = errors.New("not found on VirusTotal")
+
+func (r *releaser) virusTotalRequest(ctx context.Context, method, path string, body io.Reader, contentType string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, method, virusTotalAPI+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-apikey", r.opts.virusTotalKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errVirusTotalNotFound
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("VirusTotal returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// clamAVDetections scans pkg with a local clamscan, counting the signatures
+// it reports. clamscan exits 1 when it finds something.
+func clamAVDetections(ctx context.Context, pkg *geodePackage) (int, error) {
+	clamscan, err := exec.LookPath("clamscan")
+	if err != nil {
+		return 0, errors.New("clamscan not found in PATH")
+	}
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, clamscan, "--no-summary", "--infected", "--scan-archive=yes", "-")
+	cmd.Stdin = bytes.NewReader(pkg.data)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return 0, fmt.Errorf("clamscan failed: %w\n%s", err, strings.TrimSpace(out.String()))
+	}
+
+	detections := 0
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasSuffix(line, " FOUND") {
+			slog.Warn("ClamAV detection", "file", pkg.filename, "signature", strings.TrimSuffix(line, " FOUND"))
+			detections++
+		}
+	}
+	return detections, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// previousPackage downloads the .geode asset of rel that carries modID.
+func (r *releaser) previousPackage(ctx context.Context, rel *github.RepositoryRelease, modID string) (*geodePackage, error) {
+	for _, a := range rel.Assets {
+		if !strings.HasSuffix(a.GetName(), ".geode") {
+			continue
+		}
+		slog.Debug("Downloading previous release asset", "tag", rel.GetTagName(), "name", a.GetName())
+		data, err := r.downloadReleaseAsset(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+
+		pkg, err := newGeodePackage(a.GetName(), data)
+		if err != nil {
+			slog.Debug("Skipping unreadable previous asset", "name", a.GetName(), "error", err)
+			continue
+		}
+		if pkg.mod.ID == modID {
+			return pkg, nil
+		}
+	}
+	return nil, nil
+}
+
+// metadataDiffSection describes how the mod.json of pr differs from the one
+// in the previous release, or returns "" if nothing relevant changed.
+func (r *releaser) metadataDiffSection(ctx context.Context, pr *pendingRelease) (string, error) {
+	rel, prev, err := r.previousPackageOf(ctx, pr)
+	if err != nil || prev == nil {
+		return "", err
+	}
+
+	changes, err := diffModJSON(prev.mod, pr.pkg.mod)
+	if err != nil || len(changes) == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### What changed in metadata\n\nSince %s:\n", rel.GetTagName())
+	for _, c := range changes {
+		fmt.Fprintf(&b, "\n- %s", c)
+	}
+	return b.String(), nil
+}
+
+// diffModJSON lists the changes to the Geode and GD versions, dependencies
+// and settings between two mod.jsons.
+func diffModJSON(old, cur *ModJSON) ([]string, error) {
+	var changes []string
+	if old.Geode != cur.Geode {
+		changes = append(changes, fmt.Sprintf("Geode version: `%s` → `%s`", old.Geode, cur.Geode))
+	}
+	if oldGD, curGD := compactJSON(old.GD), compactJSON(cur.GD); oldGD != curGD {
+		changes = append(changes, fmt.Sprintf("GD version: `%s` → `%s`", oldGD, curGD))
+	}
+
+	oldDeps, err := dependencyVersions(old.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("previous mod.json dependencies: %w", err)
+	}
+	curDeps, err := dependencyVersions(cur.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("dependencies: %w", err)
+	}
+	for _, id := range sortedUnion(oldDeps, curDeps) {
+		was, inOld := oldDeps[id]
+		now, inCur := curDeps[id]
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("Added dependency `%s` (`%s`)", id, now))
+		case !inCur:
+			changes = append(changes, fmt.Sprintf("Removed dependency `%s`", id))
+		case was != now:
+			changes = append(changes, fmt.Sprintf("Dependency `%s`: `%s` → `%s`", id, was, now))
+		}
+	}
+
+	for _, key := range sortedUnion(old.Settings, cur.Settings) {
+		was, inOld := old.Settings[key]
+		now, inCur := cur.Settings[key]
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("Added setting `%s`", key))
+		case !inCur:
+			changes = append(changes, fmt.Sprintf("Removed setting `%s`", key))
+		case compactJSON(was) != compactJSON(now):
+			changes = append(changes, fmt.Sprintf("Changed setting `%s`", key))
+		}
+	}
+	return changes, nil
+}
+
+func dependencyVersions(raw json.RawMessage) (map[string]string, error) {
+	deps, err := decodeDependencies(raw)
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]string, len(deps))
+	for _, d := range deps {
+		versions[d.ID] = d.Version
+	}
+	return versions, nil
+}
+
+func sortedUnion[V any](a, b map[string]V) []string {
+	keys := slices.Collect(maps.Keys(a))
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func compactJSON(raw json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, raw); err != nil {
+		return string(raw)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// versionMilestone returns the open milestone titled after the release,
+// as "1.2.0", "v1.2.0" or the full tag name, or nil if there is none.
+func (r *releaser) versionMilestone(ctx context.Context, pr *pendingRelease) (*github.Milestone, error) {
+	if pr.milestone != nil {
+		return pr.milestone, nil
+	}
+
+	milestones, _, err := r.client.Issues.ListMilestones(ctx, r.owner, r.repo, &github.MilestoneListOptions{
+		State:       "open",
+		ListOptions: github.ListOptions{PerPage: 100},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	for _, m := range milestones {
+		switch m.GetTitle() {
+		case pr.version, "v" + pr.version, pr.tag:
+			return m, nil
+		}
+	}
+	slog.Debug("No milestone for version", "version", pr.version)
+	return nil, nil
+}
+
+func (r *releaser) closeMilestone(ctx context.Context, pr *pendingRelease) error {
+	m, err := r.versionMilestone(ctx, pr)
+	if err != nil || m == nil {
+		return err
+	}
+	if _, _, err := r.client.Issues.EditMilestone(ctx, r.owner, r.repo, m.GetNumber(), &github.Milestone{State: github.String("closed")}); err != nil {
+		return fmt.Errorf("failed to close milestone %s: %w", m.GetTitle(), err)
+	}
+	slog.Info("Closed milestone", "title", m.GetTitle(), "url", m.GetHTMLURL())
+	return nil
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ModJSON is the subset of a Geode mod.json that the releaser inspects.
+type ModJSON struct {
+	Geode        string                     `json:"geode"`
+	GD           json.RawMessage            `json:"gd"`
+	ID           string                     `json:"id"`
+	Name         string                     `json:"name"`
+	Version      string                     `json:"version"`
+	Developer    string                     `json:"developer"`
+	Developers   []string                   `json:"developers"`
+	Description  string                     `json:"description"`
+	Dependencies json.RawMessage            `json:"dependencies"`
+	Settings     map[string]json.RawMessage `json:"settings"`
+}
+
+var (
+	modIDPattern      = regexp.MustCompile(`^[a-z0-9\-_]+\.[a-z0-9\-_]+$`)
+	modVersionPattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+(-(alpha|beta|prerelease|pr)(\.\d+)?)?$`)
+	versionReqPattern = regexp.MustCompile(`^(\*|(>=|<=|=|>|<)?v?\d+\.\d+\.\d+(-[0-9A-Za-z.\-]+)?)$`)
+	gdVersionPattern  = regexp.MustCompile(`^(\*|\d+\.\d+)$`)
+)
+
+// geodePlatforms are the keys accepted in the object form of the "gd" field.
+var geodePlatforms = []string{"win", "mac", "android", "ios"}
+
+// modJSONError reports every schema violation found in a mod.json at once.
+type modJSONError struct {
+	problems []string
+}
+
+func (e *modJSONError) Error() string {
+	return "mod.json failed validation:\n  - " + strings.Join(e.problems, "\n  - ")
+}
+
+// errModJSONNotFound is returned for a package without a mod.json.
+var errModJSONNotFound = errors.New("not found inside .geode file")
+
+// modJSONPath is the path of mod.json inside a package, set by
+// -mod-json-path.
+var modJSONPath = "mod.json"
+
+// readModJSON locates mod.json inside a .geode package, decodes it and
+// validates it against the Geode mod.json schema.
+func readModJSON(geodeData []byte) (*ModJSON, error) {
+	r, err := zip.NewReader(bytes.NewReader(geodeData), int64(len(geodeData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+	found, err := findModJSON(r)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := found.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mod.json inside .geode: %w", err)
+	}
+	defer rc.Close()
+
+	slog.Debug("Found mod.json inside .geode", "path", found.Name)
+
+	raw, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mod.json: %w", err)
+	}
+	return parseModJSON(raw)
+}
+
+// findModJSON returns the mod.json entry of a package: the one at
+// modJSONPath or, with the default root path, the shallowest mod.json in a
+// package without one at the root.
+func findModJSON(r *zip.Reader) (*zip.File, error) {
+	want := strings.TrimPrefix(path.Clean("/"+modJSONPath), "/")
+	var found, fallback *zip.File
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(f.Name, "./")
+		if name == want {
+			found = f
+			break
+		}
+		if path.Base(name) == "mod.json" && (fallback == nil || strings.Count(name, "/") < strings.Count(fallback.Name, "/")) {
+			fallback = f
+		}
+	}
+	if found == nil && want == "mod.json" && fallback != nil {
+		slog.Warn("No mod.json at the package root, using a nested one", "path", fallback.Name)
+		found = fallback
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s %w", want, errModJSONNotFound)
+	}
+	return found, nil
+}
+
+func parseModJSON(raw []byte) (*ModJSON, error) {
+	var mod ModJSON
+	if err := json.Unmarshal(raw, &mod); err != nil {
+		return nil, fmt.Errorf("failed to decode mod.json: %w", err)
+	}
+	if problems := mod.validate(); len(problems) > 0 {
+		return nil, &modJSONError{problems: problems}
+	}
+	return &mod, nil
+}
+
+// validate returns a readable description of every schema violation.
+func (m *ModJSON) validate() []string {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case m.ID == "":
+		add("id: missing")
+	case !modIDPattern.MatchString(m.ID):
+		add("id: %q must look like developer.mod-name (lowercase letters, digits, - and _)", m.ID)
+	}
+
+	if strings.TrimSpace(m.Name) == "" {
+		add("name: missing")
+	}
+
+	switch {
+	case m.Version == "":
+		add("version: missing")
+	case !modVersionPattern.MatchString(m.Version):
+		add("version: %q is not a valid mod version (expected e.g. v1.2.3 or v1.2.3-beta.1)", m.Version)
+	}
+
+	switch {
+	case m.Geode == "":
+		add("geode: missing target loader version")
+	case !modVersionPattern.MatchString(m.Geode):
+		add("geode: %q is not a valid loader version", m.Geode)
+	}
+
+	if m.Developer == "" && len(m.Developers) == 0 {
+		add("developer: one of developer or developers is required")
+	}
+	for i, dev := range m.Developers {
+		if strings.TrimSpace(dev) == "" {
+			add("developers[%d]: empty name", i)
+		}
+	}
+
+	problems = append(problems, validateGD(m.GD)...)
+	problems = append(problems, validateDependencies(m.Dependencies)...)
+	return problems
+}
+
+// validateGD accepts either a single version string or a per-platform map.
+func validateGD(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return []string{"gd: missing target Geometry Dash version"}
+	}
+
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		if !gdVersionPattern.MatchString(single) {
+			return []string{fmt.Sprintf("gd: %q is not a valid Geometry Dash version", single)}
+		}
+		return nil
+	}
+
+	var perPlatform map[string]string
+	if err := json.Unmarshal(raw, &perPlatform); err != nil {
+		return []string{"gd: must be a version string or an object of platform versions"}
+	}
+
+	var problems []string
+	for _, platform := range slices.Sorted(maps.Keys(perPlatform)) {
+		version := perPlatform[platform]
+		if !isGeodePlatform(platform) {
+			problems = append(problems, fmt.Sprintf("gd.%s: unknown platform (want one of %s)", platform, strings.Join(geodePlatforms, ", ")))
+		} else if !gdVersionPattern.MatchString(version) {
+			problems = append(problems, fmt.Sprintf("gd.%s: %q is not a valid Geometry Dash version", platform, version))
+		}
+	}
+	return problems
+}
+
+// modDependency is a single entry of the dependencies field.
+type modDependency struct {
+	ID         string `json:"id"`
+	Version    string `json:"version"`
+	Importance string `json:"importance"`
+}
+
+// decodeDependencies decodes the dependencies field. Both the legacy array
+// form and the current object form keyed by mod ID are accepted.
+func decodeDependencies(raw json.RawMessage) ([]modDependency, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var list []modDependency
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, nil
+	}
+
+	var byID map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &byID); err != nil {
+		return nil, errors.New("must be an array or an object keyed by mod id")
+	}
+	for _, id := range slices.Sorted(maps.Keys(byID)) {
+		v := byID[id]
+		dep := modDependency{ID: id}
+		if err := json.Unmarshal(v, &dep.Version); err != nil {
+			if err := json.Unmarshal(v, &dep); err != nil {
+				return nil, fmt.Errorf("%s: must be a version string or an object", id)
+			}
+			dep.ID = id
+		}
+		list = append(list, dep)
+	}
+	return list, nil
+}
+
+func validateDependencies(raw json.RawMessage) []string {
+	deps, err := decodeDependencies(raw)
+	if err != nil {
+		return []string{"dependencies: " + err.Error()}
+	}
+
+	var problems []string
+	for i, dep := range deps {
+		name := dep.ID
+		if name == "" {
+			name = fmt.Sprintf("[%d]", i)
+			problems = append(problems, fmt.Sprintf("dependencies%s: missing id", name))
+		} else if !modIDPattern.MatchString(dep.ID) && dep.ID != "geode.loader" {
+			problems = append(problems, fmt.Sprintf("dependencies.%s: invalid mod id", name))
+		}
+		if dep.Version == "" {
+			problems = append(problems, fmt.Sprintf("dependencies.%s: missing version", name))
+		} else if !versionReqPattern.MatchString(dep.Version) {
+			problems = append(problems, fmt.Sprintf("dependencies.%s: %q is not a valid version requirement", name, dep.Version))
+		}
+		switch dep.Importance {
+		case "", "required", "recommended", "suggested":
+		default:
+			problems = append(problems, fmt.Sprintf("dependencies.%s: unknown importance %q", name, dep.Importance))
+		}
+	}
+	return problems
+}
+
+func isGeodePlatform(platform string) bool {
+	return slices.Contains(geodePlatforms, platform)
+}
+
+// developers returns the mod's developers from either the developer or the
+// developers field.
+func (m *ModJSON) developers() []string {
+	if len(m.Developers) > 0 {
+		return m.Developers
+	}
+	if m.Developer != "" {
+		return []string{m.Developer}
+	}
+	return nil
+}
+
+// gdVersion returns the targeted Geometry Dash version, listing each
+// platform's version when the per-platform form is used, or "" if the
+// field is missing or malformed.
+func (m *ModJSON) gdVersion() string {
+	var single string
+	if err := json.Unmarshal(m.GD, &single); err == nil {
+		return single
+	}
+	var perPlatform map[string]string
+	if err := json.Unmarshal(m.GD, &perPlatform); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, platform := range slices.Sorted(maps.Keys(perPlatform)) {
+		parts = append(parts, platform+" "+perPlatform[platform])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+const nightlyTag = "nightly"
+
+// releaseNightly publishes pr on the rolling nightly release: the nightly
+// tag is moved to pr's commit and the release's assets are replaced, so its
+// download URLs always serve the newest build. The old assets stay up until
+// their replacements are uploaded.
+func (r *releaser) releaseNightly(ctx context.Context, pr *pendingRelease, t *releaseTarget) (*releaseResult, error) {
+	client, owner, repo := r.client, r.owner, r.repo
+	pkg, commitSHA, uploads := pr.pkg, pr.commitSHA, pr.assets
+	version, tagName := t.version, t.tag
+
+	message := fmt.Sprintf("Nightly build of %s %s", pkg.mod.ID, version)
+	body := fmt.Sprintf("Latest development build of %s %s from %s, published %s.", pkg.mod.ID, version, commitSHA, time.Now().UTC().Format(time.RFC1123))
+
+	unlock, err := r.acquireLock(ctx, tagName, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	release, err := getReleaseByTag(ctx, client, owner, repo, tagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing release: %w", err)
+	}
+	var stale []*github.ReleaseAsset
+	if release == nil {
+		if err := deleteTag(ctx, client, owner, repo, tagName); err != nil {
+			return nil, fmt.Errorf("failed to delete stale tag: %w", err)
+		}
+		if err := createTag(ctx, client, owner, repo, tagName, message, commitSHA); err != nil {
+			return nil, err
+		}
+		release, _, err = client.Repositories.CreateRelease(ctx, owner, repo, &github.RepositoryRelease{
+			TagName:    github.String(tagName),
+			Name:       github.String(fmt.Sprintf("Nightly %s", pkg.mod.ID)),
+			Body:       github.String(body),
+			Prerelease: github.Bool(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create release: %w", err)
+		}
+		slog.Info("Created nightly release", "tag", tagName, "release_id", release.GetID())
+	} else {
+		stale = release.Assets
+		if err := moveTag(ctx, client, owner, repo, tagName, message, commitSHA); err != nil {
+			return nil, err
+		}
+		if release, _, err = client.Repositories.EditRelease(ctx, owner, repo, release.GetID(), &github.RepositoryRelease{Body: github.String(body)}); err != nil {
+			return nil, fmt.Errorf("failed to update release: %w", err)
+		}
+		slog.Info("Moved nightly tag", "tag", tagName, "sha", commitSHA)
+	}
+
+	res := &releaseResult{
+		ModID:       pkg.mod.ID,
+		Tag:         tagName,
+		Version:     version,
+		ReleaseID:   release.GetID(),
+		ReleaseURL:  release.GetHTMLURL(),
+		BuiltSHA256: pkg.built,
+	}
+	for _, u := range uploads {
+		asset, err := r.replaceNightlyAsset(ctx, release, stale, u)
+		if err != nil {
+			return nil, err
+		}
+		res.Assets = append(res.Assets, *asset)
+	}
+	// Remove every old asset, not just those replaced, so builds that
+	// dropped a file do not leave it behind.
+	for _, a := range stale {
+		if slices.ContainsFunc(uploads, func(u extraAsset) bool { return u.name == a.GetName() }) {
+			continue
+		}
+		slog.Debug("Deleting old nightly asset", "name", a.GetName(), "asset_id", a.GetID())
+		// A leftover staged asset may have been deleted already.
+		if _, err := client.Repositories.DeleteReleaseAsset(ctx, owner, repo, a.GetID()); err != nil && !isStatus(err, http.StatusNotFound) {
+			return nil, fmt.Errorf("failed to delete old asset %s: %w", a.GetName(), err)
+		}
+	}
+	slog.Info("Nightly release updated", "tag", tagName, "url", release.GetHTMLURL())
+	return res, nil
+}
+
+// nightlyStagingPrefix marks an asset uploaded next to the one it replaces.
+const nightlyStagingPrefix = "new-"
+
+// replaceNightlyAsset uploads u to the nightly release. An old asset of the
+// same name is only deleted once the new one is up under a staging name,
+// which then takes over the old name, so the download is never missing.
+func (r *releaser) replaceNightlyAsset(ctx context.Context, release *github.RepositoryRelease, stale []*github.ReleaseAsset, u extraAsset) (*assetResult, error) {
+	i := slices.IndexFunc(stale, func(a *github.ReleaseAsset) bool { return a.GetName() == u.name })
+	if i < 0 {
+		return r.uploadAsset(ctx, release, u.name, u.data, false)
+	}
+
+	staged := nightlyStagingPrefix + u.name
+	if err := deleteAssetNamed(ctx, r.client, r.owner, r.repo, release, staged); err != nil {
+		return nil, err
+	}
+	asset, err := r.uploadAsset(ctx, release, staged, u.data, false)
+	if err != nil {
+		return nil, err
+	}
+	old := stale[i]
+	slog.Debug("Deleting old nightly asset", "name", old.GetName(), "asset_id", old.GetID())
+	if _, err := r.client.Repositories.DeleteReleaseAsset(ctx, r.owner, r.repo, old.GetID()); err != nil {
+		return nil, fmt.Errorf("failed to delete old asset %s: %w", old.GetName(), err)
+	}
+	renamed, _, err := r.client.Repositories.EditReleaseAsset(ctx, r.owner, r.repo, asset.ID, &github.ReleaseAsset{Name: github.String(u.name)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename %s to %s: %w", staged, u.name, err)
+	}
+	asset.Name, asset.DownloadURL = renamed.GetName(), renamed.GetBrowserDownloadURL()
+	return asset, nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/mod/semver"
+)
+
+// pendingRelease is what the release notes and the post-release steps know
+// about the release being made. previous and milestone are looked up on
+// demand.
+type pendingRelease struct {
+	pkg       *geodePackage
+	tagPrefix string
+	channel   string
+	tag       string
+	version   string
+	commitSHA string
+	// assets are the files uploaded to the release, the package first.
+	assets []extraAsset
+	// manifest is the update manifest, set once the package is uploaded
+	// when -update-manifest or -updates-release is used.
+	manifest extraAsset
+
+	// release is set once the release has been created.
+	release *github.RepositoryRelease
+	// created is false when an existing release was updated.
+	created bool
+
+	previous       *github.RepositoryRelease
+	previousLoaded bool
+	// previousPkg is the package in previous, downloaded on demand.
+	previousPkg       *geodePackage
+	previousPkgLoaded bool
+	milestone         *github.Milestone
+}
+
+// releaseNotes builds the body of a new release from the enabled sections.
+// A section that cannot be generated is left out with a warning rather than
+// failing the release.
+func (r *releaser) releaseNotes(ctx context.Context, pr *pendingRelease) string {
+	opts := r.opts
+	var sections []string
+	add := func(name string, section string, err error) {
+		if err != nil {
+			slog.Warn("Failed to generate release notes section", "section", name, "error", err)
+			return
+		}
+		if section != "" {
+			sections = append(sections, section)
+		}
+	}
+
+	if opts.modInfo {
+		add("mod info", modInfoSection(pr.pkg.mod), nil)
+	}
+	var prev *github.RepositoryRelease
+	if opts.metadataDiff || opts.commitLog || opts.contributors {
+		prev = r.previousOf(ctx, pr)
+	}
+	if opts.metadataDiff && prev != nil {
+		section, err := r.metadataDiffSection(ctx, pr)
+		add("metadata diff", section, err)
+	}
+	if opts.commitLog && prev != nil {
+		section, err := r.commitLogSection(ctx, prev, pr.commitSHA)
+		add("commit log", section, err)
+	}
+	if opts.contributors && prev != nil {
+		section, err := r.contributorsSection(ctx, prev, pr.commitSHA)
+		add("contributors", section, err)
+	}
+	if opts.closeMilestone {
+		m, err := r.versionMilestone(ctx, pr)
+		var section string
+		if m != nil {
+			pr.milestone = m
+			section = fmt.Sprintf("Milestone: [%s](%s)", m.GetTitle(), m.GetHTMLURL())
+		}
+		add("milestone", section, err)
+	}
+	if opts.compatMatrix {
+		section, err := compatibilitySection(pr.pkg)
+		add("compatibility", section, err)
+	}
+	if opts.assetTable {
+		section, err := assetTableSection(pr)
+		add("asset table", section, err)
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// modInfoSection describes the released mod from its mod.json.
+func modInfoSection(mod *ModJSON) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n", cmp.Or(mod.Name, mod.ID))
+	if mod.Description != "" {
+		fmt.Fprintf(&b, "\n%s\n", mod.Description)
+	}
+	fmt.Fprintf(&b, "\n- ID: `%s`", mod.ID)
+	if devs := mod.developers(); len(devs) > 0 {
+		fmt.Fprintf(&b, "\n- Developers: %s", strings.Join(devs, ", "))
+	}
+	if mod.Geode != "" {
+		fmt.Fprintf(&b, "\n- Geode: `%s`", mod.Geode)
+	}
+	if gd := mod.gdVersion(); gd != "" {
+		fmt.Fprintf(&b, "\n- Geometry Dash: `%s`", gd)
+	}
+	return b.String()
+}
+
+// afterRelease runs the steps that follow a successful release. Like the
+// notifications, they only log their failures.
+func (r *releaser) afterRelease(ctx context.Context, pr *pendingRelease) {
+	if r.opts.releaseBranchTemplate != "" {
+		if err := r.createReleaseBranch(ctx, pr); err != nil {
+			slog.Warn("Failed to create release branch", "tag", pr.tag, "error", err)
+		}
+	}
+	if r.opts.closeMilestone {
+		if err := r.closeMilestone(ctx, pr); err != nil {
+			slog.Warn("Failed to close milestone", "tag", pr.tag, "error", err)
+		}
+	}
+	// Channel builds are prereleases and must not be offered as updates.
+	if r.opts.updatesRelease != "" && pr.manifest.data != nil && pr.channel == "" {
+		if err := r.publishUpdateManifest(ctx, pr); err != nil {
+			slog.Warn("Failed to publish update manifest", "tag", r.opts.updatesRelease, "error", err)
+		}
+	}
+	if r.opts.prunePrereleases > 0 {
+		if err := r.prunePrereleases(ctx, pr.tagPrefix, r.opts.prunePrereleases); err != nil {
+			slog.Warn("Failed to prune prereleases", "error", err)
+		}
+	}
+	// Comment only on new releases so that updating one does not repeat
+	// the comments.
+	if r.opts.commentIssues && pr.created {
+		if prev := r.previousOf(ctx, pr); prev != nil {
+			if err := r.commentShipped(ctx, pr, prev); err != nil {
+				slog.Warn("Failed to comment on shipped issues", "tag", pr.tag, "error", err)
+			}
+		}
+	}
+	if r.opts.commentPRs && pr.created {
+		if prev := r.previousOf(ctx, pr); prev != nil {
+			if err := r.commentReleasedPRs(ctx, pr, prev); err != nil {
+				slog.Warn("Failed to comment on released pull requests", "tag", pr.tag, "error", err)
+			}
+		}
+	}
+}
+
+// previousOf returns the release before pr, looking it up once.
+func (r *releaser) previousOf(ctx context.Context, pr *pendingRelease) *github.RepositoryRelease {
+	if !pr.previousLoaded {
+		var err error
+		stable := pr.channel == "" && semver.Prerelease("v"+pr.version) == ""
+		if pr.previous, err = r.previousRelease(ctx, pr.tagPrefix, pr.version, stable); err != nil {
+			slog.Warn("Failed to find the previous release", "error", err)
+		}
+		pr.previousLoaded = true
+	}
+	return pr.previous
+}
+
+// previousPackageOf returns the release before pr and its package of the
+// same mod, downloading it once. Either is nil if there is none.
+func (r *releaser) previousPackageOf(ctx context.Context, pr *pendingRelease) (*github.RepositoryRelease, *geodePackage, error) {
+	prev := r.previousOf(ctx, pr)
+	if prev == nil || pr.previousPkgLoaded {
+		return prev, pr.previousPkg, nil
+	}
+	pkg, err := r.previousPackage(ctx, prev, pr.pkg.mod.ID)
+	if err != nil {
+		return prev, nil, fmt.Errorf("failed to fetch the previous package: %w", err)
+	}
+	pr.previousPkg, pr.previousPkgLoaded = pkg, true
+	return prev, pkg, nil
+}
+
+// previousRelease returns the published release with the highest version
+// below version among those tagged with tagPrefix, or nil if there is none.
+// The release before a stable one is the previous stable release, so that
+// its notes are not made against a prerelease.
+func (r *releaser) previousRelease(ctx context.Context, tagPrefix, version string, stable bool) (*github.RepositoryRelease, error) {
+	var prev *github.RepositoryRelease
+	var prevVersion string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := r.client.Repositories.ListReleases(ctx, r.owner, r.repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list releases: %w", err)
+		}
+		for _, rel := range releases {
+			if rel.GetDraft() || (stable && rel.GetPrerelease()) || !strings.HasPrefix(rel.GetTagName(), tagPrefix) {
+				continue
+			}
+			v, err := versionFromTag(rel.GetTagName(), tagPrefix)
+			if err != nil || compareVersions(v, version) >= 0 || (stable && semver.Prerelease("v"+v) != "") {
+				continue
+			}
+			if prev == nil || compareVersions(v, prevVersion) > 0 {
+				prev, prevVersion = rel, v
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if prev != nil {
+		slog.Debug("Found previous release", "tag", prev.GetTagName())
+	}
+	return prev, nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v55/github"
+)
+
+func TestPreviousRelease(t *testing.T) {
+	release := func(tag string, draft, prerelease bool) *github.RepositoryRelease {
+		return &github.RepositoryRelease{TagName: github.String(tag), Draft: github.Bool(draft), Prerelease: github.Bool(prerelease)}
+	}
+	releases := []*github.RepositoryRelease{
+		release("v2.0.0", false, false),
+		release("v1.2.0-rc.1", false, true),
+		release("v1.1.5", true, false),
+	}
+	// Push the older releases onto the second page.
+	for i := range 150 {
+		releases = append(releases, release(fmt.Sprintf("other/v%d.0.0", i), false, false))
+	}
+	releases = append(releases, release("v1.1.0", false, false), release("v1.0.0", false, false))
+
+	client := testGitHub(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		servePages(w, r, releases, func(page []*github.RepositoryRelease) any { return page })
+	}))
+	r := &releaser{client: client, owner: "owner", repo: "repo"}
+
+	tests := []struct {
+		version string
+		stable  bool
+		want    string
+	}{
+		{"1.2.0", true, "v1.1.0"},
+		{"1.2.0-rc.2", false, "v1.2.0-rc.1"},
+		{"1.1.0", true, "v1.0.0"},
+		{"1.0.0", true, ""},
+		{"3.0.0", true, "v2.0.0"},
+	}
+	for _, tt := range tests {
+		prev, err := r.previousRelease(context.Background(), "v", tt.version, tt.stable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := prev.GetTagName(); got != tt.want {
+			t.Errorf("previousRelease(%q, stable %v) = %q, want %q", tt.version, tt.stable, got, tt.want)
+		}
+	}
+}
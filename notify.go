@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+const changelogExcerptLimit = 1000
+
+// announcement is what notifiers know about a finished release.
+type announcement struct {
+	Repo        string   `json:"repo"`
+	ModID       string   `json:"mod_id"`
+	ModName     string   `json:"mod_name"`
+	Developers  []string `json:"developers"`
+	Version     string   `json:"version"`
+	Tag         string   `json:"tag"`
+	ReleaseURL  string   `json:"release_url"`
+	DownloadURL string   `json:"download_url"`
+	Changelog   string   `json:"changelog"`
+}
+
+func newAnnouncement(repo string, pkg *geodePackage, rel *releaseResult) *announcement {
+	a := &announcement{
+		Repo:       repo,
+		ModID:      pkg.mod.ID,
+		ModName:    pkg.mod.Name,
+		Developers: pkg.mod.developers(),
+		Version:    rel.Version,
+		Tag:        rel.Tag,
+		ReleaseURL: rel.ReleaseURL,
+	}
+	if len(rel.Assets) > 0 {
+		a.DownloadURL = rel.Assets[0].DownloadURL
+	}
+
+	changelog, err := readPackageFile(pkg, "changelog.md")
+	if err != nil {
+		slog.Debug("Failed to read changelog from package", "error", err)
+	}
+	a.Changelog = changelogExcerpt(string(changelog), rel.Version, changelogExcerptLimit)
+	return a
+}
+
+var changelogHeading = regexp.MustCompile(`(?m)^#+\s*`)
+
+// changelogExcerpt returns the section of a Geode changelog.md for version,
+// or the start of the changelog if no heading mentions it, truncated to limit
+// characters.
+func changelogExcerpt(changelog, version string, limit int) string {
+	changelog = strings.TrimSpace(changelog)
+	if changelog == "" {
+		return ""
+	}
+
+	sections := changelogHeading.Split(changelog, -1)
+	excerpt := changelog
+	for _, sec := range sections {
+		heading, body, _ := strings.Cut(sec, "\n")
+		if strings.Contains(heading, version) {
+			excerpt = strings.TrimSpace(body)
+			break
+		}
+	}
+
+	if runes := []rune(excerpt); len(runes) > limit {
+		excerpt = strings.TrimSpace(string(runes[:limit])) + "…"
+	}
+	return excerpt
+}
+
+// renderTemplate executes the text/template tmpl against data.
+func renderTemplate(name, tmpl string, data any) (string, error) {
+	t, err := template.New(name).Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	var out strings.Builder
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// postJSON POSTs payload as JSON to target and fails on a non-2xx response.
+// The target is treated as a secret, since it is always a webhook, and is
+// redacted from the errors of requests that fail to send.
+func postJSON(ctx context.Context, client *http.Client, target string, payload any, header http.Header) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(withSecretPath(ctx), http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return redactURLError(http.MethodPost, target, err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return redactURLError(req.Method, target, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// redactURLError replaces a *url.Error, whose text includes the whole
+// target URL, with one naming only the host.
+func redactURLError(method, target string, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s %s: %w", method, redactURL(target), urlErr.Err)
+	}
+	return err
+}
+
+// notify announces the finished releases on every configured channel.
+// Notification failures are logged but do not fail the run, since the
+// release itself has already been published.
+func (r *releaser) notify(ctx context.Context, runID int64, announcements []*announcement) {
+	for _, a := range announcements {
+		if r.opts.discordWebhook != "" {
+			if err := notifyDiscord(ctx, r.http, r.opts.discordWebhook, a); err != nil {
+				slog.Error("Failed to post Discord announcement", "tag", a.Tag, "error", err)
+			} else {
+				slog.Info("Posted Discord announcement", "tag", a.Tag)
+			}
+		}
+		if r.opts.slack.enabled() {
+			if err := notifySlack(ctx, r.http, &r.opts.slack, a); err != nil {
+				slog.Error("Failed to post Slack notification", "tag", a.Tag, "error", err)
+			} else {
+				slog.Info("Posted Slack notification", "tag", a.Tag)
+			}
+		}
+		if r.opts.announceCategory != "" {
+			if u, err := postDiscussionAnnouncement(ctx, r.client, r.owner, r.repo, r.opts.announceCategory, a); err != nil {
+				slog.Error("Failed to post discussion announcement", "tag", a.Tag, "error", err)
+			} else {
+				slog.Info("Posted discussion announcement", "tag", a.Tag, "url", u)
+			}
+		}
+		if r.opts.smtp.enabled() {
+			if err := sendEmail(&r.opts.smtp, a); err != nil {
+				slog.Error("Failed to send release email", "tag", a.Tag, "error", err)
+			} else {
+				slog.Info("Sent release email", "tag", a.Tag, "to", r.opts.smtp.to)
+			}
+		}
+	}
+
+	sendWebhooks(ctx, r.http, r.opts.webhooks, r.opts.webhookTemplate, &webhookEvent{
+		Status:   "success",
+		Repo:     r.owner + "/" + r.repo,
+		RunID:    runID,
+		Releases: announcements,
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPostJSONRedactsURL(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	host := srv.Listener.Addr().String()
+	srv.Close()
+
+	for _, target := range []string{
+		"http://" + host + "/api/webhooks/1/SECRET",
+		"http://" + host + "/api/webhooks/1/SECRET\x7f",
+	} {
+		err := postJSON(context.Background(), http.DefaultClient, target, map[string]string{"text": "hi"}, nil)
+		if err == nil {
+			t.Fatalf("postJSON(%q) succeeded", target)
+		}
+		if strings.Contains(err.Error(), "SECRET") {
+			t.Errorf("postJSON(%q) error includes the URL: %v", target, err)
+		}
+	}
+}
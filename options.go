@@ -0,0 +1,457 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// errMissingRepo is returned by parse when the target repository could not
+// be determined.
+var errMissingRepo = errors.New("-owner and -repo are required outside a GitHub clone or Actions run")
+
+// options holds the configuration of a release run.
+type options struct {
+	owner                 string
+	repo                  string
+	branch                string
+	workflowFile          string
+	workflowFiles         []string
+	artifactOwner         string
+	artifactRepo          string
+	upstream              bool
+	noPreflight           bool
+	auditDir              string
+	otlp                  bool
+	pushgateway           string
+	checkRun              bool
+	checkRunName          string
+	commitStatus          bool
+	commitStatusContext   string
+	deploymentEnvironment string
+	runID                 int64
+	downloadRetries       int
+	noProgress            bool
+	verbosity             int
+	quiet                 bool
+	timeout               time.Duration
+	http                  httpConfig
+	tagPrefix             string
+	updateExisting        bool
+	force                 bool
+	requireNewer          bool
+	bump                  string
+	repoModJSON           string
+	pollInterval          time.Duration
+	publishIndex          bool
+	indexURL              string
+	checkDependencies     bool
+	geodeVerify           bool
+	geodeVerifyArgs       string
+	platforms             []string
+	platformsWarn         bool
+	splitPlatformList     string
+	splitPlatforms        []platformGroup
+	minLoader             string
+	targetLoader          string
+	loaderWarn            bool
+	malwareScan           string
+	malwareThreshold      int
+	malwareWarn           bool
+	virusTotalKey         string
+	discordWebhook        string
+	slack                 slackConfig
+	webhooks              stringList
+	webhookTemplate       string
+	smtp                  smtpConfig
+	announceCategory      string
+	configFile            string
+	profile               string
+	logFormat             string
+	outputFormat          string
+	artifactName          string
+	interactive           bool
+	file                  string
+	buildCmd              string
+	buildDir              string
+	assetName             string
+	extraAssets           stringList
+	uploadRetries         int
+	contentTypes          map[string]string
+	metadataDiff          bool
+	assetTable            bool
+	compatMatrix          bool
+	modInfo               bool
+	commitLog             bool
+	contributors          bool
+	closeMilestone        bool
+	commentIssues         bool
+	commentPRs            bool
+	issueLabel            string
+	releaseBranchTemplate string
+	channels              map[string]string
+	nightly               bool
+	prunePrereleases      int
+	updateManifest        bool
+	updatesRelease        string
+	cacheDir              string
+	noCache               bool
+	stateFile             string
+	lockTimeout           time.Duration
+	packageLayout         string
+	mergePackages         bool
+	stamp                 string
+	symbols               bool
+	maxGrowth             float64
+	maxGrowthBytes        int64
+	sizeWarn              bool
+	deltaPatch            bool
+	version               string
+	versionSources        []string
+	checkSourceVersion    bool
+	strict                bool
+	generateNotes         bool
+	notesConfig           string
+	discussionCategory    string
+	makeLatest            string
+	sbom                  string
+	verifyUpload          bool
+	discoveryWait         time.Duration
+	event                 string
+	actor                 string
+
+	// multiRepo is set by commands that pick the repositories themselves:
+	// the git clone in the working directory supplies no defaults and
+	// -owner and -repo are not required.
+	multiRepo bool
+
+	// Raw flag values that are post-processed into the fields above.
+	platformList      string
+	contentTypeList   stringList
+	channelList       stringList
+	filenameVersion   string
+	versionSourceList string
+	v                 bool
+	vv                bool
+	verboseAlias      bool
+}
+
+// register defines the release flags on fs.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.owner, "owner", "", "GitHub repo owner (required)")
+	fs.StringVar(&o.repo, "repo", "", "GitHub repo name (required)")
+	fs.StringVar(&o.artifactOwner, "artifact-owner", "", "Owner of the repository whose workflow builds the artifact (default -owner)")
+	fs.StringVar(&o.artifactRepo, "artifact-repo", "", "Repository whose workflow builds the artifact (default -repo)")
+	fs.BoolVar(&o.upstream, "upstream", false, "Release the artifact built in the -owner/-repo fork to the repository it was forked from")
+	fs.StringVar(&o.branch, "branch", "main", "Branch name to look for workflow runs")
+	fs.StringVar(&o.event, "event", "", "Only consider workflow runs triggered by this event, e.g. push, workflow_dispatch or schedule")
+	fs.StringVar(&o.actor, "actor", "", "Only consider workflow runs triggered by this user or bot login")
+	fs.StringVar(&o.workflowFile, "workflow", "multi-platform.yml", "Workflow filename, or a comma-separated list tried in order")
+	fs.Int64Var(&o.runID, "run-id", 0, "Release the artifact of this workflow run instead of the latest completed one")
+	fs.StringVar(&o.artifactName, "artifact", "Build Output", "Name of the workflow artifact containing the .geode packages")
+	fs.StringVar(&o.packageLayout, "package-layout", layoutAuto, "Where the .geode is in the artifact: auto, top (at its root), nested (inside a zip in it) or artifact (the artifact is the package)")
+	fs.BoolVar(&o.mergePackages, "merge-packages", false, "Merge per-platform packages of the same mod in the artifact into one .geode, as geode package merge does")
+	fs.StringVar(&o.stamp, "stamp", "", "Stamp the commit, run ID and build time into each package before uploading: build-info (a build-info.json) or mod-json (a build-info key in mod.json)")
+	fs.BoolVar(&o.symbols, "symbols", false, "Move PDBs and dSYM bundles out of the packages into a symbols-<version>.zip asset, together with those and any unstripped .so files in the artifact")
+	fs.Float64Var(&o.maxGrowth, "max-growth", 0, "Fail if the package grew by more than this percentage since the previous release (0 disables)")
+	fs.Int64Var(&o.maxGrowthBytes, "max-growth-bytes", 0, "Fail if the package grew by more than this many bytes since the previous release (0 disables)")
+	fs.BoolVar(&o.sizeWarn, "size-warn", false, "Only warn when the package grew beyond -max-growth or -max-growth-bytes instead of failing")
+	fs.BoolVar(&o.deltaPatch, "delta-patch", false, "Upload a VCDIFF (xdelta3) patch from the previous release's package to the new one")
+	fs.StringVar(&modJSONPath, "mod-json-path", "mod.json", "Path of mod.json inside the .geode package")
+	fs.StringVar(&o.filenameVersion, "filename-version-regex", "", "Regexp taking the version from the .geode file name when mod.json cannot be parsed, from its \"version\" or first group")
+	fs.StringVar(&o.version, "version", "", "Version to release, for the \"flag\" version source")
+	fs.StringVar(&o.versionSourceList, "version-source", "flag,mod.json", "Comma-separated version sources tried in order: flag, mod.json, version-file (VERSION in the repo) or cmake (CMakeLists.txt project VERSION)")
+	fs.BoolVar(&o.checkSourceVersion, "check-source-version", false, "Fail unless the packaged version matches -repo-mod-json at the built commit")
+	fs.BoolVar(&o.strict, "strict", false, "Fail instead of warning when the branch has moved past the built commit")
+	fs.StringVar(&o.file, "file", "", "Release this local .geode file instead of a workflow artifact")
+	fs.StringVar(&o.buildCmd, "build-cmd", "", "Run this shell command (e.g. \"geode build\") and release the .geode file it produces")
+	fs.StringVar(&o.buildDir, "build-dir", ".", "Directory to search for the .geode file produced by -build-cmd")
+	fs.BoolVar(&o.interactive, "interactive", false, "Pick the run and artifact from a list and confirm the release at a prompt")
+	fs.StringVar(&o.tagPrefix, "tag-prefix", "v", "Prefix prepended to the normalized version to form the tag name")
+	fs.StringVar(&o.assetName, "asset-name", "", "Go text/template for the uploaded asset name, e.g. {{.ModID}}-{{.Version}}-{{.Platform}}.geode (default: keep the packaged file name)")
+	fs.Var(&o.extraAssets, "extra-asset", "Glob of additional files to upload, matched in the artifact first and then on disk (repeatable)")
+	fs.BoolVar(&o.metadataDiff, "metadata-diff", false, "Add the mod.json changes since the previous release to the release notes")
+	fs.BoolVar(&o.modInfo, "mod-info", true, "Name the release after the mod and describe its ID, developers and Geode and GD versions in the release notes")
+	fs.BoolVar(&o.compatMatrix, "compat-matrix", true, "Add a table of the platforms with binaries and their targeted GD versions to the release notes")
+	fs.BoolVar(&o.assetTable, "asset-table", true, "Add a table of the uploaded assets with their sizes, SHA-256 digests and platforms to the release notes")
+	fs.BoolVar(&o.commitLog, "commit-log", false, "Add the commits since the previous release to the release notes")
+	fs.BoolVar(&o.contributors, "contributors", false, "Add a section thanking the authors of the commits since the previous release to the release notes")
+	fs.BoolVar(&o.closeMilestone, "close-milestone", false, "Link and close the open milestone named after the version")
+	fs.BoolVar(&o.commentIssues, "comment-issues", false, "Comment on issues and pull requests closed since the previous release that they shipped")
+	fs.BoolVar(&o.commentPRs, "comment-prs", false, "Comment on each pull request merged between the previous release's tag and this one that it is included in the release")
+	fs.StringVar(&o.issueLabel, "issue-label", "", "Label to add to the issues and pull requests commented on by -comment-issues")
+	fs.StringVar(&o.releaseBranchTemplate, "release-branch-template", "", "Go text/template for a branch to create at the released commit, e.g. release/{{.Major}}.{{.Minor}}")
+	fs.Var(&o.channelList, "channel", "Release builds of a branch as prereleases on a channel, as branch=channel, e.g. develop=beta (repeatable)")
+	fs.BoolVar(&o.nightly, "nightly", false, "Replace the build on a single rolling \"nightly\" release instead of releasing the version")
+	fs.BoolVar(&o.updateManifest, "update-manifest", false, "Attach a latest.json update manifest with the version, download URL, SHA-256 and required Geode version to the release")
+	fs.StringVar(&o.updatesRelease, "updates-release", "", "Also keep the update manifest on the release with this tag, created if missing, for a stable update check URL")
+	fs.IntVar(&o.prunePrereleases, "prune-prereleases", 0, "After a release, delete all but the newest N prereleases and their tags (0 keeps all)")
+	fs.BoolVar(&o.generateNotes, "generate-notes", false, "Add GitHub's automatically generated release notes")
+	fs.StringVar(&o.notesConfig, "notes-config", "", "Repository path of the release notes configuration for -generate-notes (default .github/release.yml)")
+	fs.StringVar(&o.sbom, "sbom", "", "Attach an SBOM of the package's files and mod.json dependencies to the release: cyclonedx or spdx")
+	fs.StringVar(&o.makeLatest, "make-latest", "", "Whether the release becomes the repository's latest: true, false or legacy (by date and version; default true)")
+	fs.BoolVar(&o.updateExisting, "update-existing", false, "Upload the asset to an existing release for the version instead of failing")
+	fs.BoolVar(&o.force, "force", false, "Delete and recreate an existing release and tag for the version")
+	fs.BoolVar(&o.requireNewer, "require-newer", false, "Fail unless the version is greater than the latest existing release")
+	fs.StringVar(&o.bump, "bump", "", "Bump the version (major, minor, patch or auto from commit messages), push it and release the resulting build")
+	fs.StringVar(&o.repoModJSON, "repo-mod-json", "mod.json", "Path of mod.json in the repository")
+	fs.DurationVar(&o.pollInterval, "poll-interval", 30*time.Second, "How often to poll for a workflow run to complete")
+	fs.BoolVar(&o.publishIndex, "publish-index", false, "Submit the released version to the Geode mods index (token from GEODE_INDEX_TOKEN)")
+	fs.StringVar(&o.indexURL, "index-url", defaultIndexURL, "Base URL of the Geode index API")
+	fs.BoolVar(&o.checkDependencies, "check-dependencies", false, "Fail if a required mod.json dependency is missing from the Geode index at a satisfying version")
+	fs.BoolVar(&o.geodeVerify, "geode-verify", false, "Verify packages with the geode CLI, if installed, before releasing")
+	fs.StringVar(&o.geodeVerifyArgs, "geode-verify-args", "package check {file}", "Arguments for the geode CLI verification; {file} is replaced by the package path")
+	fs.StringVar(&o.platformList, "platforms", "", "Comma-separated platforms each package must ship binaries for (windows, macos, ios, android32, android64, or win, mac, android)")
+	fs.BoolVar(&o.platformsWarn, "platforms-warn", false, "Only warn about missing platform binaries instead of failing")
+	fs.StringVar(&o.splitPlatformList, "split-platforms", "", "Also upload stripped variants of multi-platform packages, one per comma-separated platform or alias (e.g. win,android)")
+	fs.StringVar(&o.minLoader, "min-loader", "", "Fail if mod.json targets a Geode loader older than this version")
+	fs.StringVar(&o.targetLoader, "target-loader", "", "Fail if mod.json targets a Geode loader newer than this version, or than the newest on the index with \"latest\"")
+	fs.BoolVar(&o.loaderWarn, "loader-warn", false, "Only warn about loader version mismatches instead of failing")
+	fs.StringVar(&o.malwareScan, "malware-scan", "", "Scan packages for malware before releasing: virustotal (needs $VIRUSTOTAL_API_KEY) or clamav (a local clamscan)")
+	fs.IntVar(&o.malwareThreshold, "malware-threshold", 0, "Number of malware detections tolerated before the release is blocked")
+	fs.BoolVar(&o.malwareWarn, "malware-warn", false, "Only warn when the malware scan exceeds the threshold instead of failing")
+	fs.StringVar(&o.discordWebhook, "discord-webhook", "", "Discord webhook URL to announce releases to (default $DISCORD_WEBHOOK_URL)")
+	fs.StringVar(&o.slack.webhookURL, "slack-webhook", "", "Slack incoming webhook URL to notify (default $SLACK_WEBHOOK_URL)")
+	fs.StringVar(&o.slack.channel, "slack-channel", "", "Slack channel to post to with the bot token in $SLACK_BOT_TOKEN")
+	fs.StringVar(&o.slack.template, "slack-template", defaultSlackTemplate, "Go text/template for the Slack message")
+	fs.Var(&o.webhooks, "webhook", "URL to POST a JSON payload to when the run succeeds or fails (repeatable)")
+	fs.StringVar(&o.webhookTemplate, "webhook-template", "", "File with a Go text/template for the -webhook JSON payload")
+	fs.StringVar(&o.smtp.host, "smtp-host", "", "SMTP server for release summary emails")
+	fs.IntVar(&o.smtp.port, "smtp-port", 587, "SMTP server port (465 for implicit TLS)")
+	fs.StringVar(&o.smtp.username, "smtp-username", "", "SMTP username")
+	fs.StringVar(&o.smtp.password, "smtp-password", "", "SMTP password (default $SMTP_PASSWORD; best kept in the config file or environment)")
+	fs.StringVar(&o.smtp.from, "smtp-from", "", "Sender address for release emails")
+	fs.Var(&o.smtp.to, "smtp-to", "Recipient address for release emails (repeatable)")
+	fs.StringVar(&o.smtp.template, "email-template", defaultEmailTemplate, "Go text/template for the release email body")
+	fs.StringVar(&o.announceCategory, "announce-discussion", "", "Discussion category to post a release announcement thread in")
+	fs.StringVar(&o.discussionCategory, "discussion-category", "", "Discussion category for GitHub to open a thread linked to the release in")
+	fs.StringVar(&o.pushgateway, "pushgateway", "", "Prometheus pushgateway URL to push run metrics to")
+	fs.BoolVar(&o.checkRun, "check-run", false, "Report the outcome as a check run on the tagged commit (needs checks: write)")
+	fs.StringVar(&o.checkRunName, "check-run-name", "gwtreleaser", "Name of the -check-run check")
+	fs.BoolVar(&o.commitStatus, "commit-status", false, "Report the outcome as a commit status on the tagged commit")
+	fs.StringVar(&o.commitStatusContext, "commit-status-context", "release/published", "Context of the -commit-status status")
+	fs.StringVar(&o.deploymentEnvironment, "deployment-environment", "", "Track the release as a deployment of the tagged commit to this environment, e.g. production")
+	fs.BoolVar(&o.otlp, "otlp", false, "Export OpenTelemetry traces of each phase over OTLP/HTTP, configured by the OTEL_EXPORTER_OTLP_* environment variables")
+	fs.StringVar(&o.auditDir, "audit-dir", "", "Directory to write a JSON audit record of each run's changes to GitHub into")
+	fs.BoolVar(&o.noPreflight, "no-preflight", false, "Skip checking the token's permissions before starting")
+	fs.StringVar(&o.configFile, "config", "", "JSON config file of flag settings (default "+defaultConfigFile+" if present)")
+	fs.StringVar(&o.profile, "profile", "", "Named profile from the config file's \"profiles\" object whose settings override the top-level ones")
+	fs.DurationVar(&o.lockTimeout, "lock-timeout", 10*time.Minute, "Age after which another run's release lock is considered abandoned")
+	fs.StringVar(&o.stateFile, "state", "", "File recording released runs; a run found in it is not released again (watch defaults to "+defaultStateFile+")")
+	fs.DurationVar(&o.discoveryWait, "discovery-wait", 0, "How long to keep retrying when the workflow run or artifact is not listed yet (default 2m in GitHub Actions)")
+	fs.IntVar(&o.downloadRetries, "download-retries", 5, "Number of times to resume an interrupted artifact download")
+	fs.Var(&o.contentTypeList, "content-type", "Content type for assets with an extension, as .ext=type (repeatable; .geode defaults to application/zip)")
+	fs.IntVar(&o.uploadRetries, "upload-retries", 3, "Number of times to retry a failed release asset upload")
+	fs.BoolVar(&o.verifyUpload, "verify-upload", false, "Download each uploaded asset back and check its SHA-256, retrying the upload on a mismatch")
+	fs.StringVar(&o.cacheDir, "cache-dir", "", "Directory to cache downloaded artifacts in (default the user cache directory's gwtreleaser folder)")
+	fs.BoolVar(&o.noCache, "no-cache", false, "Always download artifacts instead of reusing cached copies")
+	fs.BoolVar(&o.noProgress, "no-progress", false, "Disable transfer progress output")
+	fs.BoolVar(&o.v, "v", false, "Enable debug output")
+	fs.BoolVar(&o.vv, "vv", false, "Enable trace output, including HTTP requests")
+	fs.BoolVar(&o.verboseAlias, "verbose", false, "Alias for -v")
+	fs.BoolVar(&o.quiet, "quiet", false, "Only print errors and the final result")
+	fs.StringVar(&o.logFormat, "log-format", "text", "Log output format: text or json")
+	fs.StringVar(&o.outputFormat, "output", "text", "Result output format: text or json")
+	fs.StringVar(&o.http.caCert, "ca-cert", "", "PEM file with additional CA certificates to trust")
+	fs.StringVar(&o.http.tlsMinVersion, "tls-min-version", "", "Minimum TLS version to accept: 1.2 or 1.3 (default 1.2)")
+	fs.DurationVar(&o.http.timeout, "http-timeout", 0, "Limit on each HTTP request including reading the body, which for large artifacts must cover the whole download (0 disables it)")
+	fs.DurationVar(&o.http.dialTimeout, "http-dial-timeout", 30*time.Second, "Timeout for opening HTTP connections")
+	fs.DurationVar(&o.http.tlsHandshakeTimeout, "http-tls-handshake-timeout", 10*time.Second, "Timeout for TLS handshakes")
+	fs.DurationVar(&o.http.responseHeaderTimeout, "http-response-header-timeout", 0, "Timeout for waiting for response headers after sending a request (0 disables it)")
+	fs.DurationVar(&o.http.idleConnTimeout, "http-idle-conn-timeout", 90*time.Second, "How long idle HTTP connections are kept open")
+	fs.IntVar(&o.http.maxIdleConns, "http-max-idle-conns", 100, "Maximum idle HTTP connections across all hosts")
+	fs.IntVar(&o.http.maxIdleConnsPerHost, "http-max-idle-conns-per-host", 0, "Maximum idle HTTP connections per host (0 uses Go's default of 2)")
+	fs.DurationVar(&o.timeout, "timeout", 0, "Abort the run after this long (0 disables the timeout)")
+}
+
+// parse parses args and fills in unset flags from, in order, the action
+// inputs, the config file, the GitHub Actions environment and the local git
+// clone. It also sets up logging and validates the result.
+func (o *options) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// The action inputs and config file may configure logging themselves,
+	// so what they log is replayed once the logger is set up.
+	flushLogs := bufferLogs()
+	defer flushLogs()
+
+	if err := applyDefaults(fs, actionInputs(fs), "action inputs"); err != nil {
+		return err
+	}
+
+	configFile, required := o.configFile, true
+	if configFile == "" {
+		configFile, required = defaultConfigFile, false
+	}
+	if err := applyConfigFile(fs, configFile, required, o.profile); err != nil {
+		return err
+	}
+
+	showProgress = !o.noProgress && !o.quiet
+	switch {
+	case o.vv:
+		o.verbosity = 2
+	case o.v || o.verboseAlias:
+		o.verbosity = 1
+	}
+
+	level := logLevel(o.verbosity)
+	if o.quiet {
+		level = slog.LevelError
+	}
+	if err := setupLogger(o.logFormat, level); err != nil {
+		return err
+	}
+	flushLogs()
+	if o.otlp && tracerProvider == nil {
+		if err := setupTracing(); err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+	}
+
+	if err := applyDefaults(fs, actionsDefaults(splitWorkflows(o.workflowFile)), "GitHub Actions environment"); err != nil {
+		return err
+	}
+	if !o.multiRepo {
+		if err := applyDefaults(fs, gitDefaults(), "local git clone"); err != nil {
+			return err
+		}
+	}
+
+	if (o.owner == "" || o.repo == "") && !o.multiRepo {
+		return errMissingRepo
+	}
+	if o.upstream && (o.artifactOwner != "" || o.artifactRepo != "" || o.bump != "") {
+		return errors.New("-upstream cannot be combined with -artifact-owner, -artifact-repo or -bump")
+	}
+	if o.artifactOwner == "" {
+		o.artifactOwner = o.owner
+	}
+	if o.artifactRepo == "" {
+		o.artifactRepo = o.repo
+	}
+	if o.discordWebhook == "" {
+		o.discordWebhook = os.Getenv("DISCORD_WEBHOOK_URL")
+	}
+	if o.smtp.password == "" {
+		o.smtp.password = os.Getenv("SMTP_PASSWORD")
+	}
+	if o.minLoader != "" {
+		if _, err := normalizeVersion(o.minLoader); err != nil {
+			return fmt.Errorf("invalid -min-loader: %w", err)
+		}
+	}
+	if o.targetLoader != "" && o.targetLoader != "latest" {
+		if _, err := normalizeVersion(o.targetLoader); err != nil {
+			return fmt.Errorf("invalid -target-loader: %w", err)
+		}
+	}
+	switch o.malwareScan {
+	case "", scanClamAV:
+	case scanVirusTotal:
+		o.virusTotalKey = os.Getenv("VIRUSTOTAL_API_KEY")
+		if o.virusTotalKey == "" {
+			return errors.New("-malware-scan virustotal requires the VIRUSTOTAL_API_KEY environment variable")
+		}
+	default:
+		return fmt.Errorf("unknown -malware-scan scanner %q (want %s or %s)", o.malwareScan, scanVirusTotal, scanClamAV)
+	}
+	if o.slack.webhookURL == "" {
+		o.slack.webhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+	}
+	if o.slack.channel != "" {
+		o.slack.botToken = os.Getenv("SLACK_BOT_TOKEN")
+		if o.slack.botToken == "" && o.slack.webhookURL == "" {
+			return errors.New("-slack-channel requires the SLACK_BOT_TOKEN environment variable")
+		}
+	}
+
+	var err error
+	if o.workflowFiles = splitWorkflows(o.workflowFile); len(o.workflowFiles) == 0 {
+		return errors.New("-workflow must name at least one workflow file")
+	}
+	if o.platforms, err = parsePlatforms(o.platformList); err != nil {
+		return err
+	}
+	if o.splitPlatforms, err = parsePlatformGroups(o.splitPlatformList); err != nil {
+		return err
+	}
+	if o.contentTypes, err = parseContentTypes(o.contentTypeList); err != nil {
+		return err
+	}
+	if o.channels, err = parseChannels(o.channelList); err != nil {
+		return err
+	}
+	if o.versionSources, err = parseVersionSources(o.versionSourceList); err != nil {
+		return err
+	}
+	if o.filenameVersion != "" {
+		if filenameVersionPattern, err = regexp.Compile(o.filenameVersion); err != nil {
+			return fmt.Errorf("invalid -filename-version-regex: %w", err)
+		}
+	}
+	switch o.bump {
+	case "", "major", "minor", "patch", "auto":
+	default:
+		return fmt.Errorf("unknown bump kind %q (want major, minor, patch or auto)", o.bump)
+	}
+	if o.file != "" && o.buildCmd != "" {
+		return errors.New("-file and -build-cmd are mutually exclusive")
+	}
+	if (o.file != "" || o.buildCmd != "") && (o.bump != "" || o.runID != 0) {
+		return errors.New("-file and -build-cmd cannot be combined with -bump or -run-id")
+	}
+	if o.bump != "" && o.crossRepo() {
+		return errors.New("-bump cannot be combined with -artifact-owner or -artifact-repo")
+	}
+	if o.nightly && (o.bump != "" || o.force || o.updateExisting || o.publishIndex) {
+		return errors.New("-nightly cannot be combined with -bump, -force, -update-existing or -publish-index")
+	}
+	if o.discussionCategory != "" && o.announceCategory != "" {
+		return errors.New("-discussion-category and -announce-discussion would both open a discussion for the release")
+	}
+	if o.interactive {
+		if o.bump != "" {
+			return errors.New("-interactive cannot be combined with -bump")
+		}
+		if !isTerminal(os.Stdin) {
+			return errors.New("-interactive requires a terminal on stdin")
+		}
+	}
+	if !slices.Contains(packageLayouts, o.packageLayout) {
+		return fmt.Errorf("unknown package layout %q (want %s)", o.packageLayout, strings.Join(packageLayouts, ", "))
+	}
+	switch o.makeLatest {
+	case "", "true", "false", "legacy":
+	default:
+		return fmt.Errorf("unknown -make-latest value %q (want true, false or legacy)", o.makeLatest)
+	}
+	switch o.stamp {
+	case "", stampBuildInfo, stampModJSON:
+	default:
+		return fmt.Errorf("unknown -stamp target %q (want %s or %s)", o.stamp, stampBuildInfo, stampModJSON)
+	}
+	switch o.sbom {
+	case "", sbomCycloneDX, sbomSPDX:
+	default:
+		return fmt.Errorf("unknown -sbom format %q (want %s or %s)", o.sbom, sbomCycloneDX, sbomSPDX)
+	}
+	if o.outputFormat != "text" && o.outputFormat != "json" {
+		return fmt.Errorf("unknown output format %q (want text or json)", o.outputFormat)
+	}
+	return nil
+}
+
+// crossRepo reports whether the artifact is built in another repository than
+// the one released to.
+func (o *options) crossRepo() bool {
+	return !strings.EqualFold(o.artifactOwner+"/"+o.artifactRepo, o.owner+"/"+o.repo)
+}
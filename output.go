@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// runResult is the final summary of a run, printed on stdout so that
+// downstream automation can consume it without scraping the logs.
+type runResult struct {
+	RunID    int64           `json:"run_id"`
+	Commit   string          `json:"commit"`
+	Releases []releaseResult `json:"releases"`
+}
+
+// releaseResult describes one release created by a run.
+type releaseResult struct {
+	ModID      string `json:"mod_id"`
+	Tag        string `json:"tag"`
+	Version    string `json:"version"`
+	ReleaseID  int64  `json:"release_id"`
+	ReleaseURL string `json:"release_url"`
+	// BuiltSHA256 is the digest of the package as built, which differs
+	// from that of its asset when -symbols or -stamp rewrote it.
+	BuiltSHA256 string        `json:"built_sha256"`
+	Assets      []assetResult `json:"assets"`
+}
+
+type assetResult struct {
+	Name        string `json:"name"`
+	ID          int64  `json:"id"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	DownloadURL string `json:"download_url"`
+}
+
+// writeResult prints res to w in the requested output format.
+func writeResult(w io.Writer, format string, res *runResult) error {
+	switch format {
+	case "text":
+		for _, rel := range res.Releases {
+			if _, err := fmt.Fprintf(w, "Released %s: %s\n", rel.Tag, rel.ReleaseURL); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	default:
+		return fmt.Errorf("unknown output format %q (want text or json)", format)
+	}
+}
+
+// writeActionOutputs appends the release-url, tag and asset-ids step outputs
+// to the $GITHUB_OUTPUT file. Values of several releases are comma-separated.
+func writeActionOutputs(path string, res *runResult) error {
+	var urls, tags, assetIDs []string
+	for _, rel := range res.Releases {
+		urls = append(urls, rel.ReleaseURL)
+		tags = append(tags, rel.Tag)
+		for _, a := range rel.Assets {
+			assetIDs = append(assetIDs, strconv.FormatInt(a.ID, 10))
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(f, "release-url=%s\ntag=%s\nasset-ids=%s\n",
+		strings.Join(urls, ","), strings.Join(tags, ","), strings.Join(assetIDs, ","))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
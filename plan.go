@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// releasePlan is what a run will release, written by "plan" and carried out
+// unchanged by "apply".
+type releasePlan struct {
+	Repository   string           `json:"repository"`
+	RunID        int64            `json:"run_id,omitempty"`
+	Artifact     string           `json:"artifact,omitempty"`
+	File         string           `json:"file,omitempty"`
+	SourceSHA256 string           `json:"source_sha256"`
+	Commit       string           `json:"commit"`
+	Nightly      bool             `json:"nightly,omitempty"`
+	Releases     []plannedRelease `json:"releases"`
+}
+
+type plannedRelease struct {
+	ModID   string         `json:"mod_id"`
+	Version string         `json:"version"`
+	Tag     string         `json:"tag"`
+	Assets  []plannedAsset `json:"assets"`
+}
+
+type plannedAsset struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	// Generated is set for the update manifest, which holds the package's
+	// download URL and so is planned by name only.
+	Generated bool `json:"generated,omitempty"`
+}
+
+// planMain implements "plan": it works out the release without changing
+// anything and writes the plan as JSON.
+func planMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	opts.register(fs)
+	out := fs.String("o", "-", "File to write the plan to, - for stdout")
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if opts.buildCmd != "" || opts.bump != "" {
+		fmt.Fprintln(os.Stderr, "-build-cmd and -bump cannot be planned, as they change what is released")
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	if err == nil {
+		r.planning = true
+		_, err = r.run(ctx)
+	}
+	if err == nil && r.plan == nil {
+		err = errors.New("nothing to plan, the run is already released")
+	}
+	if err != nil {
+		code := exitCodeOf(err)
+		slog.Error("Planning failed", "error", err, "exit_code", code)
+		return code
+	}
+
+	data, err := json.MarshalIndent(r.plan, "", "  ")
+	if err == nil {
+		data = append(data, '\n')
+		if *out == "-" {
+			_, err = os.Stdout.Write(data)
+		} else {
+			err = os.WriteFile(*out, data, 0o644)
+		}
+	}
+	if err != nil {
+		slog.Error("Failed to write plan", "error", err)
+		return exitFailure
+	}
+	return 0
+}
+
+// applyMain implements "apply": it releases exactly what a plan file
+// describes, failing if anything about the build or release differs.
+func applyMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	opts.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage of apply [flags] plan.json:\n")
+		fs.PrintDefaults()
+	}
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	if opts.buildCmd != "" || opts.bump != "" || opts.interactive {
+		fmt.Fprintln(os.Stderr, "-build-cmd, -bump and -interactive cannot be used with apply; the plan names the build")
+		return exitUsage
+	}
+
+	plan, err := loadPlan(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if !strings.EqualFold(plan.Repository, opts.owner+"/"+opts.repo) {
+		fmt.Fprintf(os.Stderr, "the plan is for %s, not %s/%s\n", plan.Repository, opts.owner, opts.repo)
+		return exitUsage
+	}
+	// A run ID may come from the Actions environment, but must then be the
+	// planned one.
+	if (opts.runID != 0 && opts.runID != plan.RunID) || (opts.file != "" && opts.file != plan.File) {
+		fmt.Fprintln(os.Stderr, "-run-id and -file must match the plan if set")
+		return exitUsage
+	}
+	opts.runID, opts.file = plan.RunID, plan.File
+	if plan.Artifact != "" {
+		opts.artifactName = plan.Artifact
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	if err != nil {
+		return reportResult(opts.outputFormat, nil, err)
+	}
+	r.plan = plan
+	res, err := r.run(ctx)
+	return reportResult(opts.outputFormat, res, err)
+}
+
+func loadPlan(path string) (*releasePlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+	plan := new(releasePlan)
+	if err := json.Unmarshal(data, plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan %s: %w", path, err)
+	}
+	return plan, nil
+}
+
+// buildPlan describes the release of pending at commitSHA. source is the
+// artifact zip, or the package file without an artifact.
+func (r *releaser) buildPlan(run *github.WorkflowRun, source []byte, commitSHA string, pending []*pendingRelease, targets []*releaseTarget) *releasePlan {
+	plan := &releasePlan{
+		Repository:   r.owner + "/" + r.repo,
+		File:         r.opts.file,
+		SourceSHA256: sha256Hex(source),
+		Commit:       commitSHA,
+		Nightly:      r.opts.nightly,
+	}
+	if run != nil {
+		plan.RunID, plan.Artifact = run.GetID(), r.opts.artifactName
+	}
+	for i, pr := range pending {
+		rel := plannedRelease{
+			ModID:   pr.pkg.mod.ID,
+			Version: targets[i].version,
+			Tag:     targets[i].tag,
+		}
+		for _, a := range pr.assets {
+			rel.Assets = append(rel.Assets, plannedAsset{Name: a.name, Size: len(a.data), SHA256: sha256Hex(a.data)})
+		}
+		if r.releasesManifest() {
+			rel.Assets = append(rel.Assets, plannedAsset{Name: targets[i].manifestName, Generated: true})
+		}
+		plan.Releases = append(plan.Releases, rel)
+	}
+	return plan
+}
+
+// checkPlan reports the first way in which got, the plan for what is about
+// to be released, differs from the approved plan want.
+func checkPlan(want, got *releasePlan) error {
+	switch {
+	case got.SourceSHA256 != want.SourceSHA256:
+		return fmt.Errorf("the build differs from the plan: sha256 %s, planned %s", got.SourceSHA256, want.SourceSHA256)
+	case got.Nightly != want.Nightly:
+		return fmt.Errorf("-nightly is %t but the plan was made with %t", got.Nightly, want.Nightly)
+	case len(got.Releases) != len(want.Releases):
+		return fmt.Errorf("%d releases would be made but %d are planned", len(got.Releases), len(want.Releases))
+	}
+	for i, g := range got.Releases {
+		w := want.Releases[i]
+		switch {
+		case g.ModID != w.ModID:
+			return fmt.Errorf("release %d is of %s but %s is planned", i+1, g.ModID, w.ModID)
+		case g.Tag != w.Tag || g.Version != w.Version:
+			return fmt.Errorf("%s would be released as %s (%s) but %s (%s) is planned", g.ModID, g.Tag, g.Version, w.Tag, w.Version)
+		case len(g.Assets) != len(w.Assets):
+			return fmt.Errorf("%s would get %d assets but %d are planned", g.Tag, len(g.Assets), len(w.Assets))
+		}
+		for j, a := range g.Assets {
+			if a != w.Assets[j] {
+				return fmt.Errorf("asset %s of %s differs from the planned %s", a.Name, g.Tag, w.Assets[j].Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckPlan(t *testing.T) {
+	plan := func() *releasePlan {
+		return &releasePlan{
+			Repository:   "owner/repo",
+			SourceSHA256: "abc",
+			Commit:       "0123456",
+			Releases: []plannedRelease{{
+				ModID:   "my.mod",
+				Version: "1.0.0",
+				Tag:     "v1.0.0",
+				Assets:  []plannedAsset{{Name: "my.mod.geode", Size: 10, SHA256: "def"}},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		change  func(p *releasePlan)
+		wantErr string
+	}{
+		{"identical", func(*releasePlan) {}, ""},
+		{"commit only", func(p *releasePlan) { p.Commit = "7654321" }, ""},
+		{"other build", func(p *releasePlan) { p.SourceSHA256 = "xyz" }, "the build differs"},
+		{"nightly", func(p *releasePlan) { p.Nightly = true }, "-nightly is true"},
+		{"extra release", func(p *releasePlan) { p.Releases = append(p.Releases, p.Releases[0]) }, "2 releases would be made"},
+		{"other mod", func(p *releasePlan) { p.Releases[0].ModID = "other.mod" }, "release 1 is of other.mod"},
+		{"other tag", func(p *releasePlan) { p.Releases[0].Tag = "v1.0.1" }, "would be released as v1.0.1"},
+		{"other version", func(p *releasePlan) { p.Releases[0].Version = "1.0.1" }, "(1.0.1)"},
+		{"extra asset", func(p *releasePlan) {
+			p.Releases[0].Assets = append(p.Releases[0].Assets, plannedAsset{Name: "extra.txt"})
+		}, "would get 2 assets"},
+		{"asset contents", func(p *releasePlan) { p.Releases[0].Assets[0].SHA256 = "ghi" }, "asset my.mod.geode of v1.0.0 differs"},
+		{"asset name", func(p *releasePlan) { p.Releases[0].Assets[0].Name = "renamed.geode" }, "asset renamed.geode"},
+		{"unplanned variant", func(p *releasePlan) {
+			p.Releases[0].Assets = append(p.Releases[0].Assets, plannedAsset{Name: "my.mod-win.geode", Size: 8, SHA256: "jkl"})
+		}, "would get 2 assets"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := plan()
+			tt.change(got)
+			err := checkPlan(plan(), got)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("checkPlan() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("checkPlan() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckPlanVariants(t *testing.T) {
+	plan := func(variant plannedAsset) *releasePlan {
+		return &releasePlan{
+			SourceSHA256: "abc",
+			Releases: []plannedRelease{{
+				ModID: "my.mod", Version: "1.0.0", Tag: "v1.0.0",
+				Assets: []plannedAsset{
+					{Name: "my.mod.geode", Size: 10, SHA256: "def"},
+					variant,
+					{Name: "latest.json", Generated: true},
+				},
+			}},
+		}
+	}
+	want := plan(plannedAsset{Name: "my.mod-win.geode", Size: 8, SHA256: "jkl"})
+
+	if err := checkPlan(want, plan(plannedAsset{Name: "my.mod-win.geode", Size: 8, SHA256: "jkl"})); err != nil {
+		t.Errorf("checkPlan() = %v for the planned variant", err)
+	}
+	err := checkPlan(want, plan(plannedAsset{Name: "my.mod-win.geode", Size: 8, SHA256: "mno"}))
+	if err == nil || !strings.Contains(err.Error(), "asset my.mod-win.geode of v1.0.0 differs") {
+		t.Errorf("checkPlan() = %v for a changed variant", err)
+	}
+	err = checkPlan(want, plan(plannedAsset{Name: "my.mod-mac.geode", Size: 8, SHA256: "jkl"}))
+	if err == nil || !strings.Contains(err.Error(), "asset my.mod-mac.geode") {
+		t.Errorf("checkPlan() = %v for another variant", err)
+	}
+}
+
+func TestBuildPlanAssets(t *testing.T) {
+	r := &releaser{owner: "owner", repo: "repo", opts: &options{
+		splitPlatforms: []platformGroup{{"win", []string{"windows"}}, {"android", []string{"android64"}}},
+		updateManifest: true,
+	}}
+	pkg := &geodePackage{filename: "my.mod.geode", mod: &ModJSON{ID: "my.mod", Version: "1.0.0"}, data: testZip(t, "mod.json", "{}", "my.mod.dll", "win", "my.mod.android64.so", "a64")}
+	target := &releaseTarget{version: "1.0.0", tag: "v1.0.0", assetName: "my.mod.geode", manifestName: "latest.json"}
+	extras := []extraAsset{{name: "README.md", data: []byte("readme")}}
+
+	pr, err := r.pendingReleaseOf(context.Background(), pkg, target, extras, "0123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := r.buildPlan(nil, pkg.data, "0123456", []*pendingRelease{pr}, []*releaseTarget{target})
+
+	var names []string
+	for _, a := range plan.Releases[0].Assets {
+		names = append(names, a.Name)
+	}
+	want := []string{"my.mod.geode", "my.mod-win.geode", "my.mod-android.geode", "README.md", "latest.json"}
+	if !slices.Equal(names, want) {
+		t.Errorf("planned assets = %v, want %v", names, want)
+	}
+	for i, a := range pr.assets {
+		if p := plan.Releases[0].Assets[i]; p.Size != len(a.data) || p.SHA256 != sha256Hex(a.data) {
+			t.Errorf("planned %s does not describe the uploaded asset", p.Name)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+)
+
+// platformBinaries maps each Geode platform to the suffix of the binary the
+// loader expects next to mod.json, named after the mod ID.
+var platformBinaries = map[string]string{
+	"windows":   ".dll",
+	"macos":     ".dylib",
+	"ios":       ".ios.dylib",
+	"android32": ".android32.so",
+	"android64": ".android64.so",
+}
+
+var platformOrder = []string{"windows", "macos", "ios", "android32", "android64"}
+
+var platformAliases = map[string][]string{
+	"win":     {"windows"},
+	"mac":     {"macos"},
+	"android": {"android32", "android64"},
+}
+
+// parsePlatforms parses a comma-separated platform list such as
+// "win,mac,android", expanding aliases.
+func parsePlatforms(list string) ([]string, error) {
+	var platforms []string
+	for _, p := range strings.Split(list, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		expanded, ok := platformAliases[p]
+		if !ok {
+			if _, known := platformBinaries[p]; !known {
+				return nil, fmt.Errorf("unknown platform %q (want one of %s, win, mac, android)", p, strings.Join(platformOrder, ", "))
+			}
+			expanded = []string{p}
+		}
+		for _, e := range expanded {
+			if !slices.Contains(platforms, e) {
+				platforms = append(platforms, e)
+			}
+		}
+	}
+	return platforms, nil
+}
+
+// packagePlatforms returns the platforms pkg ships a binary for.
+func packagePlatforms(pkg *geodePackage) ([]string, error) {
+	r, err := zip.NewReader(bytes.NewReader(pkg.data), int64(len(pkg.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+
+	names := make(map[string]bool, len(r.File))
+	for _, f := range r.File {
+		names[path.Base(f.Name)] = true
+	}
+
+	var present []string
+	for _, p := range platformOrder {
+		if names[pkg.mod.ID+platformBinaries[p]] {
+			present = append(present, p)
+		}
+	}
+	return present, nil
+}
+
+// checkPlatforms reports the expected platforms pkg has no binary for.
+// Missing binaries fail the check unless warnOnly is set.
+func checkPlatforms(pkg *geodePackage, expected []string, warnOnly bool) error {
+	present, err := packagePlatforms(pkg)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, p := range expected {
+		if !slices.Contains(present, p) {
+			missing = append(missing, fmt.Sprintf("%s (%s%s)", p, pkg.mod.ID, platformBinaries[p]))
+		}
+	}
+	if len(missing) == 0 {
+		slog.Debug("All expected platform binaries present", "file", pkg.filename, "platforms", present)
+		return nil
+	}
+
+	if warnOnly {
+		slog.Warn("Package is missing platform binaries", "file", pkg.filename, "missing", missing)
+		return nil
+	}
+	return fmt.Errorf("%s is missing binaries for %s", pkg.filename, strings.Join(missing, ", "))
+}
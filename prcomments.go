@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// releasedPullRequests returns the pull requests merged by the commits
+// between the base and head refs, each once.
+func (r *releaser) releasedPullRequests(ctx context.Context, base, head string) ([]*github.PullRequest, error) {
+	commits, err := r.compareCommits(ctx, base, head)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []*github.PullRequest
+	seen := make(map[int]bool)
+	for _, c := range commits {
+		found, _, err := r.client.PullRequests.ListPullRequestsWithCommit(ctx, r.owner, r.repo, c.GetSHA(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pull requests of %.7s: %w", c.GetSHA(), err)
+		}
+		for _, p := range found {
+			if p.MergedAt == nil || seen[p.GetNumber()] {
+				continue
+			}
+			seen[p.GetNumber()] = true
+			prs = append(prs, p)
+		}
+	}
+	return prs, nil
+}
+
+// commentReleasedPRs tells every pull request merged between prev's tag and
+// the released commit which release it is included in.
+func (r *releaser) commentReleasedPRs(ctx context.Context, pr *pendingRelease, prev *github.RepositoryRelease) error {
+	prs, err := r.releasedPullRequests(ctx, prev.GetTagName(), pr.commitSHA)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Included in release [%s](%s).", pr.tag, pr.release.GetHTMLURL())
+	for _, p := range prs {
+		n := p.GetNumber()
+		if _, _, err := r.client.Issues.CreateComment(ctx, r.owner, r.repo, n, &github.IssueComment{Body: github.String(body)}); err != nil {
+			return fmt.Errorf("failed to comment on #%d: %w", n, err)
+		}
+		slog.Debug("Commented on released pull request", "number", n)
+	}
+	slog.Info("Commented on released pull requests", "tag", pr.tag, "since", prev.GetTagName(), "count", len(prs))
+	return nil
+}
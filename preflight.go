@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// preflight checks that the token can do what the run needs, so a missing
+// permission fails up front rather than with a 403 half way through the
+// release.
+func (r *releaser) preflight(ctx context.Context) error {
+	var missing []string
+
+	repo, resp, err := r.client.Repositories.Get(ctx, r.owner, r.repo)
+	if err != nil {
+		return fmt.Errorf("failed to get repository %s/%s: %w", r.owner, r.repo, err)
+	}
+	// Classic tokens list their scopes; fine-grained and Actions tokens do
+	// not, and only the repository permissions below apply to them.
+	if header := resp.Header.Get("X-OAuth-Scopes"); header != "" {
+		scopes := strings.Split(strings.ReplaceAll(header, " ", ""), ",")
+		if !slices.Contains(scopes, "repo") && (repo.GetPrivate() || !slices.Contains(scopes, "public_repo")) {
+			missing = append(missing, "the repo scope")
+		}
+	}
+	if perms := repo.GetPermissions(); len(perms) > 0 && !perms["push"] {
+		missing = append(missing, fmt.Sprintf("contents: write on %s", repo.GetFullName()))
+	}
+
+	if r.opts.file == "" && r.opts.buildCmd == "" {
+		_, _, err := r.client.Actions.ListWorkflows(ctx, r.artifactOwner, r.artifactRepo, &github.ListOptions{PerPage: 1})
+		switch {
+		case isStatus(err, http.StatusForbidden) || isStatus(err, http.StatusNotFound):
+			missing = append(missing, fmt.Sprintf("actions: read on %s/%s", r.artifactOwner, r.artifactRepo))
+		case err != nil:
+			return fmt.Errorf("failed to check Actions access: %w", err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return withExitCode(exitAuth, fmt.Errorf("the token is missing %s", strings.Join(missing, " and ")))
+	}
+	slog.Debug("Token permissions look sufficient")
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+var showProgress = true
+
+const (
+	progressTTYInterval  = 200 * time.Millisecond
+	progressLineInterval = 5 * time.Second
+)
+
+// progress reports the state of a byte transfer on stderr. On a terminal the
+// status line is redrawn in place; otherwise a line is printed every few
+// seconds so CI logs stay readable.
+type progress struct {
+	label    string
+	total    int64
+	done     int64
+	start    time.Time
+	last     time.Time
+	tty      bool
+	interval time.Duration
+}
+
+func newProgress(label string, total int64) *progress {
+	tty := isTerminal(os.Stderr)
+	interval := progressLineInterval
+	if tty {
+		interval = progressTTYInterval
+	}
+	return &progress{
+		label:    label,
+		total:    total,
+		start:    time.Now(),
+		tty:      tty,
+		interval: interval,
+	}
+}
+
+// Write counts len(b) transferred bytes so a progress can sit behind an
+// io.MultiWriter or io.TeeReader.
+func (p *progress) Write(b []byte) (int, error) {
+	p.add(int64(len(b)))
+	return len(b), nil
+}
+
+func (p *progress) add(n int64) {
+	p.done += n
+	if now := time.Now(); now.Sub(p.last) >= p.interval {
+		p.last = now
+		p.render()
+	}
+}
+
+// reset restarts the count, e.g. when a download has to start over.
+func (p *progress) reset(total int64) {
+	p.done = 0
+	p.total = total
+}
+
+// finish prints the final state and terminates the status line.
+func (p *progress) finish() {
+	if !showProgress {
+		return
+	}
+	p.render()
+	if p.tty {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func (p *progress) render() {
+	if !showProgress {
+		return
+	}
+
+	line := fmt.Sprintf("%s: %s", p.label, formatBytes(p.done))
+	if p.total > 0 {
+		line += fmt.Sprintf(" / %s (%d%%)", formatBytes(p.total), p.done*100/p.total)
+	}
+	if elapsed := time.Since(p.start).Seconds(); elapsed > 0 {
+		line += fmt.Sprintf(" %s/s", formatBytes(int64(float64(p.done)/elapsed)))
+	}
+
+	if p.tty {
+		fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+	} else {
+		fmt.Fprintln(os.Stderr, line)
+	}
+}
+
+// progressReader wraps r so every read is counted against p.
+func progressReader(r io.Reader, p *progress) io.Reader {
+	return io.TeeReader(r, p)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// prunePrereleases deletes the published prereleases tagged with tagPrefix,
+// and their tags, except for the keep newest by version.
+func (r *releaser) prunePrereleases(ctx context.Context, tagPrefix string, keep int) error {
+	type prerelease struct {
+		rel     *github.RepositoryRelease
+		version string
+	}
+
+	var pre []prerelease
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := r.client.Repositories.ListReleases(ctx, r.owner, r.repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+		for _, rel := range releases {
+			if !rel.GetPrerelease() || rel.GetDraft() || !strings.HasPrefix(rel.GetTagName(), tagPrefix) {
+				continue
+			}
+			if v, err := versionFromTag(rel.GetTagName(), tagPrefix); err == nil {
+				pre = append(pre, prerelease{rel, v})
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(pre) <= keep {
+		return nil
+	}
+
+	slices.SortFunc(pre, func(a, b prerelease) int { return compareVersions(b.version, a.version) })
+	for _, p := range pre[keep:] {
+		tag := p.rel.GetTagName()
+		slog.Info("Pruning old prerelease", "tag", tag, "release_id", p.rel.GetID())
+		if _, err := r.client.Repositories.DeleteRelease(ctx, r.owner, r.repo, p.rel.GetID()); err != nil {
+			return fmt.Errorf("failed to delete prerelease %s: %w", tag, err)
+		}
+		if err := deleteTag(ctx, r.client, r.owner, r.repo, tag); err != nil {
+			return fmt.Errorf("failed to delete tag %s: %w", tag, err)
+		}
+	}
+	return nil
+}
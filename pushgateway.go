@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// runMetrics are the per-run figures pushed to -pushgateway.
+type runMetrics struct {
+	start      time.Time
+	downloaded int
+	res        *runResult
+	err        error
+}
+
+// pushMetrics pushes m to the Prometheus pushgateway under the job
+// "gwtreleaser" and a group per repository, logging rather than returning
+// failures. The request replaces only the metrics it carries, so the time of
+// the last success survives failed runs.
+func (r *releaser) pushMetrics(ctx context.Context, m *runMetrics) {
+	body := m.text(time.Now())
+	target := fmt.Sprintf("%s/metrics/job/gwtreleaser/repository/%s", strings.TrimSuffix(r.opts.pushgateway, "/"), url.PathEscape(r.owner+"_"+r.repo))
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, target, bytes.NewBufferString(body))
+	if err != nil {
+		slog.Error("Failed to push metrics", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := r.http.Do(req)
+	if err != nil {
+		slog.Error("Failed to push metrics", "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("Failed to push metrics", "status", resp.Status)
+		return
+	}
+	slog.Debug("Pushed metrics", "url", target)
+}
+
+// text renders m in the Prometheus text exposition format as of now. Each
+// metric has one HELP and TYPE header however many samples it has.
+func (m *runMetrics) text(now time.Time) string {
+	var b strings.Builder
+	described := make(map[string]bool)
+	metric := func(name, help string, value float64, labels ...string) {
+		if !described[name] {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+			described[name] = true
+		}
+		b.WriteString(name)
+		if len(labels) > 0 {
+			var pairs []string
+			for i := 0; i+1 < len(labels); i += 2 {
+				pairs = append(pairs, labels[i]+`="`+labelEscaper.Replace(labels[i+1])+`"`)
+			}
+			b.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		fmt.Fprintf(&b, " %g\n", value)
+	}
+
+	uploaded := 0
+	if m.res != nil {
+		for _, rel := range m.res.Releases {
+			for _, a := range rel.Assets {
+				uploaded += int(a.Size)
+			}
+		}
+	}
+	success := 0.0
+	if m.err == nil {
+		success = 1
+	}
+	metric("gwtreleaser_run_duration_seconds", "Duration of the last release run.", now.Sub(m.start).Seconds())
+	metric("gwtreleaser_run_success", "Whether the last release run succeeded.", success)
+	metric("gwtreleaser_downloaded_bytes", "Artifact bytes downloaded by the last release run.", float64(m.downloaded))
+	metric("gwtreleaser_uploaded_bytes", "Asset bytes uploaded by the last release run.", float64(uploaded))
+	if m.err == nil {
+		metric("gwtreleaser_last_success_timestamp_seconds", "When a release run last succeeded.", float64(now.Unix()))
+		for _, rel := range m.res.Releases {
+			metric("gwtreleaser_release_info", "The release made by the last successful run.", 1, "mod_id", rel.ModID, "version", rel.Version, "tag", rel.Tag)
+		}
+	}
+	return b.String()
+}
+
+// labelEscaper escapes a label value for the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunMetricsText(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	res := &runResult{Releases: []releaseResult{
+		{ModID: "a.mod", Version: "1.0.0", Tag: "a.mod/v1.0.0", Assets: []assetResult{{Size: 100}, {Size: 20}}},
+		{ModID: "b.mod", Version: "2.0.0", Tag: `b.mod/v2.0.0"`, Assets: []assetResult{{Size: 5}}},
+	}}
+
+	t.Run("success", func(t *testing.T) {
+		m := &runMetrics{start: now.Add(-90 * time.Second), downloaded: 4096, res: res}
+		want := `# HELP gwtreleaser_run_duration_seconds Duration of the last release run.
+# TYPE gwtreleaser_run_duration_seconds gauge
+gwtreleaser_run_duration_seconds 90
+# HELP gwtreleaser_run_success Whether the last release run succeeded.
+# TYPE gwtreleaser_run_success gauge
+gwtreleaser_run_success 1
+# HELP gwtreleaser_downloaded_bytes Artifact bytes downloaded by the last release run.
+# TYPE gwtreleaser_downloaded_bytes gauge
+gwtreleaser_downloaded_bytes 4096
+# HELP gwtreleaser_uploaded_bytes Asset bytes uploaded by the last release run.
+# TYPE gwtreleaser_uploaded_bytes gauge
+gwtreleaser_uploaded_bytes 125
+# HELP gwtreleaser_last_success_timestamp_seconds When a release run last succeeded.
+# TYPE gwtreleaser_last_success_timestamp_seconds gauge
+gwtreleaser_last_success_timestamp_seconds 1.7e+09
+# HELP gwtreleaser_release_info The release made by the last successful run.
+# TYPE gwtreleaser_release_info gauge
+gwtreleaser_release_info{mod_id="a.mod",version="1.0.0",tag="a.mod/v1.0.0"} 1
+gwtreleaser_release_info{mod_id="b.mod",version="2.0.0",tag="b.mod/v2.0.0\""} 1
+`
+		if got := m.text(now); got != want {
+			t.Errorf("text() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		m := &runMetrics{start: now, err: errors.New("boom")}
+		got := m.text(now)
+		if !strings.Contains(got, "gwtreleaser_run_success 0\n") {
+			t.Errorf("text() does not report the failure:\n%s", got)
+		}
+		for _, name := range []string{"gwtreleaser_last_success_timestamp_seconds", "gwtreleaser_release_info"} {
+			if strings.Contains(got, name) {
+				t.Errorf("text() reports %s for a failed run", name)
+			}
+		}
+	})
+}
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// createTag creates an annotated tag object for sha and points
+// refs/tags/<name> at it.
+func createTag(ctx context.Context, client *github.Client, owner, repo, name, message, sha string) error {
+	tagSHA, err := createTagObject(ctx, client, owner, repo, name, message, sha)
+	if err != nil {
+		return err
+	}
+
+	refTag := &github.Reference{
+		Ref: github.String("refs/tags/" + name),
+		Object: &github.GitObject{
+			SHA: github.String(tagSHA),
+		},
+	}
+
+	_, _, err = client.Git.CreateRef(ctx, owner, repo, refTag)
+	if isStatus(err, http.StatusUnprocessableEntity) {
+		return withExitCode(exitTagExists, fmt.Errorf("tag %s already exists: %w", name, err))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create tag ref: %w", err)
+	}
+	return nil
+}
+
+// moveTag points the existing refs/tags/<name> at a new annotated tag
+// object for sha.
+func moveTag(ctx context.Context, client *github.Client, owner, repo, name, message, sha string) error {
+	tagSHA, err := createTagObject(ctx, client, owner, repo, name, message, sha)
+	if err != nil {
+		return err
+	}
+
+	refTag := &github.Reference{
+		Ref: github.String("refs/tags/" + name),
+		Object: &github.GitObject{
+			SHA: github.String(tagSHA),
+		},
+	}
+	if _, _, err := client.Git.UpdateRef(ctx, owner, repo, refTag, true); err != nil {
+		return fmt.Errorf("failed to move tag ref: %w", err)
+	}
+	return nil
+}
+
+func createTagObject(ctx context.Context, client *github.Client, owner, repo, name, message, sha string) (string, error) {
+	slog.Debug("Creating git tag object", "tag", name)
+	tag := &github.Tag{
+		Tag:     github.String(name),
+		Message: github.String(message),
+		Object: &github.GitObject{
+			Type: github.String("commit"),
+			SHA:  github.String(sha),
+		},
+		Tagger: &github.CommitAuthor{
+			Name:  github.String("GitHub Actions Bot"),
+			Email: github.String("actions@github.com"),
+		},
+	}
+
+	createdTag, _, err := client.Git.CreateTag(ctx, owner, repo, tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to create git tag object: %w", err)
+	}
+	slog.Debug("Created tag object", "sha", createdTag.GetSHA())
+	return createdTag.GetSHA(), nil
+}
+
+// deleteTag removes refs/tags/<name>. A missing tag is not an error.
+func deleteTag(ctx context.Context, client *github.Client, owner, repo, name string) error {
+	_, err := client.Git.DeleteRef(ctx, owner, repo, "refs/tags/"+name)
+	if isStatus(err, http.StatusNotFound) || isStatus(err, http.StatusUnprocessableEntity) {
+		return nil
+	}
+	return err
+}
+
+// getReleaseByTag returns the release for tag, or nil if there is none.
+func getReleaseByTag(ctx context.Context, client *github.Client, owner, repo, tag string) (*github.RepositoryRelease, error) {
+	release, _, err := client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	if isStatus(err, http.StatusNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// deleteAssetNamed removes the asset called name from release, if present,
+// so that it can be uploaded again.
+func deleteAssetNamed(ctx context.Context, client *github.Client, owner, repo string, release *github.RepositoryRelease, name string) error {
+	for _, a := range release.Assets {
+		if a.GetName() != name {
+			continue
+		}
+		slog.Info("Replacing existing release asset", "name", name, "asset_id", a.GetID())
+		if _, err := client.Repositories.DeleteReleaseAsset(ctx, owner, repo, a.GetID()); err != nil {
+			return fmt.Errorf("failed to delete existing asset %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// assetNameData is the data available to the -asset-name template.
+type assetNameData struct {
+	ModID    string
+	Name     string
+	Version  string
+	Tag      string
+	Platform string
+	Filename string
+}
+
+// assetName returns the name to upload pkg under. Without a template the
+// file name from the artifact is kept. Platform is "universal" for packages
+// with binaries for every platform and otherwise lists them joined by "-".
+func assetName(tmpl string, pkg *geodePackage, version, tag string) (string, error) {
+	if tmpl == "" {
+		return pkg.filename, nil
+	}
+
+	platforms, err := packagePlatforms(pkg)
+	if err != nil {
+		return "", err
+	}
+	platform := strings.Join(platforms, "-")
+	if len(platforms) == len(platformOrder) {
+		platform = "universal"
+	}
+
+	name, err := renderTemplate("asset name", tmpl, assetNameData{
+		ModID:    pkg.mod.ID,
+		Name:     pkg.mod.Name,
+		Version:  version,
+		Tag:      tag,
+		Platform: platform,
+		Filename: pkg.filename,
+	})
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, "/\\") {
+		return "", fmt.Errorf("asset name template produced an invalid file name %q", name)
+	}
+	return name, nil
+}
+
+// requireNewerThanLatest fails unless version is strictly greater than the
+// highest published release tagged with tagPrefix, so that each mod of a
+// multi-mod repository is compared with its own releases. allowEqual
+// permits re-releasing that version itself, for use with -update-existing
+// and -force. A release with the prefix whose tag is not a version fails
+// the check, since it cannot be compared.
+func requireNewerThanLatest(ctx context.Context, client *github.Client, owner, repo, tagPrefix, version string, allowEqual bool) error {
+	var latest, latestVersion string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		releases, resp, err := client.Repositories.ListReleases(ctx, owner, repo, opts)
+		if err != nil {
+			return fmt.Errorf("failed to list releases: %w", err)
+		}
+		for _, rel := range releases {
+			if rel.GetDraft() || rel.GetPrerelease() || !strings.HasPrefix(rel.GetTagName(), tagPrefix) {
+				continue
+			}
+			v, err := versionFromTag(rel.GetTagName(), tagPrefix)
+			if err != nil {
+				return fmt.Errorf("cannot compare against release %s: %w", rel.GetTagName(), err)
+			}
+			if latest == "" || compareVersions(v, latestVersion) > 0 {
+				latest, latestVersion = rel.GetTagName(), v
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if latest == "" {
+		slog.Debug("No previous release, skipping monotonic version check", "tag_prefix", tagPrefix)
+		return nil
+	}
+
+	cmp := compareVersions(version, latestVersion)
+	if cmp > 0 || (cmp == 0 && allowEqual) {
+		slog.Debug("Version is newer than latest release", "version", version, "latest", latestVersion, "tag", latest)
+		return nil
+	}
+	return fmt.Errorf("version %s is not newer than the latest release %s", version, latestVersion)
+}
+
+// releaseTarget is what a package is released as: its version, possibly
+// suffixed for a channel, the tag and the name of its asset.
+type releaseTarget struct {
+	version   string
+	channel   string
+	tagPrefix string
+	tag       string
+	assetName string
+	// manifestName is the asset name of the update manifest.
+	manifestName string
+}
+
+// targetOf works out the release target of pkg at commitSHA. Tags are
+// namespaced by mod ID when the artifact carries several mods.
+func (r *releaser) targetOf(ctx context.Context, pkg *geodePackage, commitSHA string, multi bool) (*releaseTarget, error) {
+	opts := r.opts
+	version, err := r.resolveVersion(ctx, pkg, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+	t := &releaseTarget{version: version}
+
+	if opts.nightly {
+		t.tag = nightlyTag
+		if multi {
+			t.tag = pkg.mod.ID + "/" + nightlyTag
+		}
+	} else {
+		t.channel = opts.channels[opts.branch]
+		if t.channel != "" {
+			if t.version, err = channelVersion(version, t.channel, r.source.GetRunNumber()); err != nil {
+				return nil, err
+			}
+			slog.Info("Releasing to channel", "branch", opts.branch, "channel", t.channel, "version", t.version)
+		}
+
+		t.tagPrefix = opts.tagPrefix
+		if multi {
+			t.tagPrefix = pkg.mod.ID + "/" + t.tagPrefix
+		}
+		t.tag = t.tagPrefix + t.version
+
+		if opts.requireNewer {
+			if err := requireNewerThanLatest(ctx, r.client, r.owner, r.repo, t.tagPrefix, t.version, opts.updateExisting || opts.force); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if t.assetName, err = assetName(opts.assetName, pkg, t.version, t.tag); err != nil {
+		return nil, err
+	}
+	t.manifestName = updateManifestName(pkg, multi)
+	return t, nil
+}
+
+// pendingReleaseOf returns the release of pkg for t at commitSHA with every
+// asset it uploads before the update manifest. Planning and releasing both
+// use it, so that a plan lists exactly what is uploaded.
+func (r *releaser) pendingReleaseOf(ctx context.Context, pkg *geodePackage, t *releaseTarget, extras []extraAsset, commitSHA string) (*pendingRelease, error) {
+	uploads, err := r.packageUploads(pkg, t, extras)
+	if err != nil {
+		return nil, err
+	}
+	pr := &pendingRelease{pkg: pkg, tagPrefix: t.tagPrefix, channel: t.channel, tag: t.tag, version: t.version, commitSHA: commitSHA, assets: uploads}
+
+	// The rolling nightly release has no previous version to patch from.
+	if r.opts.deltaPatch && !r.opts.nightly {
+		patch, err := r.deltaPatch(ctx, pr)
+		switch {
+		case err != nil:
+			slog.Warn("Failed to build delta patch", "tag", t.tag, "error", err)
+		case patch != nil:
+			pr.assets = append(pr.assets, *patch)
+		}
+	}
+	return pr, nil
+}
+
+// releasesManifest reports whether releasePackage uploads an update
+// manifest next to the package.
+func (r *releaser) releasesManifest() bool {
+	return r.opts.updateManifest && !r.opts.nightly
+}
+
+// releasePackage tags pr's commit for t, creates the release and uploads
+// pr's assets to it.
+func (r *releaser) releasePackage(ctx context.Context, pr *pendingRelease, t *releaseTarget) (*releaseResult, error) {
+	client, owner, repo, opts := r.client, r.owner, r.repo, r.opts
+	pkg, commitSHA := pr.pkg, pr.commitSHA
+	version, channel, tagName := t.version, t.channel, t.tag
+
+	unlock, err := r.acquireLock(ctx, tagName, commitSHA)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	existing, err := getReleaseByTag(ctx, client, owner, repo, tagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing release: %w", err)
+	}
+
+	if opts.maxGrowth > 0 || opts.maxGrowthBytes > 0 {
+		if err := r.checkSizeGrowth(ctx, pr); err != nil {
+			return nil, err
+		}
+	}
+
+	var createdRelease *github.RepositoryRelease
+	switch {
+	case existing == nil:
+	case opts.updateExisting:
+		slog.Info("Updating existing release", "tag", tagName, "release_id", existing.GetID())
+		createdRelease = existing
+	case opts.force:
+		slog.Warn("Deleting existing release", "tag", tagName, "release_id", existing.GetID())
+		if _, err := client.Repositories.DeleteRelease(ctx, owner, repo, existing.GetID()); err != nil {
+			return nil, fmt.Errorf("failed to delete existing release: %w", err)
+		}
+	default:
+		return nil, withExitCode(exitTagExists, fmt.Errorf("release %s already exists at %s; use -update-existing or -force to overwrite it", tagName, existing.GetHTMLURL()))
+	}
+
+	if createdRelease == nil {
+		if opts.force {
+			if err := deleteTag(ctx, client, owner, repo, tagName); err != nil {
+				return nil, fmt.Errorf("failed to delete existing tag: %w", err)
+			}
+		}
+
+		tagCtx, span := startSpan(ctx, "tag", attribute.String("tag", tagName), attribute.String("commit", commitSHA))
+		err = createTag(tagCtx, client, owner, repo, tagName, fmt.Sprintf("Tag for version %s", version), commitSHA)
+		endSpan(span, err)
+		if err != nil {
+			return nil, err
+		}
+		r.rb.add("delete tag "+tagName, func(ctx context.Context) error {
+			return deleteTag(ctx, client, owner, repo, tagName)
+		})
+		slog.Info("Created tag", "tag", tagName)
+
+		slog.Debug("Creating release", "tag", tagName)
+		release := &github.RepositoryRelease{
+			TagName: github.String(tagName),
+		}
+		title := "Release"
+		if opts.modInfo && pkg.mod.Name != "" {
+			title = pkg.mod.Name
+		}
+		release.Name = github.String(fmt.Sprintf("%s %s", title, tagName))
+		if channel != "" {
+			release.Name = github.String(fmt.Sprintf("%s %s (%s)", title, tagName, channel))
+			release.Prerelease = github.Bool(true)
+		}
+		if opts.makeLatest != "" {
+			release.MakeLatest = github.String(opts.makeLatest)
+		}
+		if opts.discussionCategory != "" {
+			release.DiscussionCategoryName = github.String(opts.discussionCategory)
+		}
+		body := r.releaseNotes(ctx, pr)
+		switch {
+		case opts.generateNotes && opts.notesConfig != "":
+			generated, err := r.generateNotes(ctx, pr, opts.notesConfig)
+			if err != nil {
+				return nil, err
+			}
+			body = strings.TrimSpace(body + "\n\n" + generated)
+		case opts.generateNotes:
+			// GitHub appends its notes to the body we send.
+			release.GenerateReleaseNotes = github.Bool(true)
+		}
+		if body != "" {
+			release.Body = github.String(body)
+		}
+		releaseCtx, span := startSpan(ctx, "create release", attribute.String("tag", tagName))
+		createdRelease, _, err = client.Repositories.CreateRelease(releaseCtx, owner, repo, release)
+		endSpan(span, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create release: %w", err)
+		}
+		slog.Debug("Created release", "release_id", createdRelease.GetID())
+		releaseID := createdRelease.GetID()
+		r.rb.add("delete release "+tagName, func(ctx context.Context) error {
+			_, err := client.Repositories.DeleteRelease(ctx, owner, repo, releaseID)
+			return err
+		})
+	}
+
+	res := &releaseResult{
+		ModID:       pkg.mod.ID,
+		Tag:         tagName,
+		Version:     version,
+		ReleaseID:   createdRelease.GetID(),
+		ReleaseURL:  createdRelease.GetHTMLURL(),
+		BuiltSHA256: pkg.built,
+	}
+	replace := createdRelease == existing
+	for _, u := range pr.assets {
+		asset, err := r.uploadAsset(ctx, createdRelease, u.name, u.data, replace)
+		if err != nil {
+			return nil, err
+		}
+		res.Assets = append(res.Assets, *asset)
+	}
+	if opts.updateManifest || opts.updatesRelease != "" {
+		data, err := newUpdateManifest(pkg, version, &res.Assets[0])
+		if err != nil {
+			return nil, err
+		}
+		pr.manifest = extraAsset{name: t.manifestName, data: data}
+		if r.releasesManifest() {
+			asset, err := r.uploadAsset(ctx, createdRelease, pr.manifest.name, data, replace)
+			if err != nil {
+				return nil, err
+			}
+			res.Assets = append(res.Assets, *asset)
+		}
+	}
+
+	slog.Info("Release created and assets uploaded successfully", "tag", tagName, "url", createdRelease.GetHTMLURL())
+	pr.release, pr.created = createdRelease, !replace
+	r.afterRelease(ctx, pr)
+	return res, nil
+}
+
+// packageUploads lists the assets released with pkg: the package itself,
+// its -split-platforms variants, the extras, its debug symbols and, with
+// -sbom, its SBOM.
+func (r *releaser) packageUploads(pkg *geodePackage, t *releaseTarget, extras []extraAsset) ([]extraAsset, error) {
+	uploads := []extraAsset{{name: t.assetName, data: pkg.data}}
+	if len(r.opts.splitPlatforms) > 0 {
+		variants, err := platformVariants(pkg, t.assetName, r.opts.splitPlatforms)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, variants...)
+	}
+	uploads = append(uploads, extras...)
+	if len(pkg.symbols) > 0 {
+		bundle, err := symbolsBundle(t.version, pkg.symbols)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, bundle)
+	}
+	if r.opts.sbom != "" {
+		sbom, err := r.sbomAsset(pkg, t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate SBOM: %w", err)
+		}
+		uploads = append(uploads, sbom)
+	}
+	return uploads, nil
+}
+
+// uploadAsset uploads data as an asset called name on release. With replace
+// set, an existing asset of that name is deleted first.
+func (r *releaser) uploadAsset(ctx context.Context, release *github.RepositoryRelease, name string, data []byte, replace bool) (res *assetResult, err error) {
+	ctx, span := startSpan(ctx, "upload", attribute.String("asset.name", name), attribute.Int("asset.bytes", len(data)))
+	defer func() { endSpan(span, err) }()
+
+	if replace {
+		if err := deleteAssetNamed(ctx, r.client, r.owner, r.repo, release, name); err != nil {
+			return nil, err
+		}
+	}
+
+	contentType := assetContentType(name, r.opts.contentTypes)
+	var asset *github.ReleaseAsset
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		slog.Debug("Uploading release asset", "name", name, "content_type", contentType, "attempt", attempt+1)
+		start := time.Now()
+		var err error
+		asset, err = uploadReleaseAsset(ctx, r.client, r.owner, r.repo, release.GetID(), name, contentType, bytes.NewReader(data), int64(len(data)))
+		if err == nil && r.opts.verifyUpload {
+			err = r.verifyUploadedAsset(ctx, asset, data)
+		}
+		if err == nil {
+			slog.Info("Uploaded release asset", "name", name, "bytes", len(data), "duration", time.Since(start))
+			break
+		}
+		if ctx.Err() != nil || !retryableUpload(err) || attempt >= r.opts.uploadRetries {
+			return nil, withExitCode(exitUploadFailed, fmt.Errorf("failed to upload release asset %s: %w", name, err))
+		}
+
+		// A failed upload can leave a broken asset behind that blocks
+		// every later attempt with the same name.
+		slog.Warn("Upload attempt failed, retrying", "name", name, "attempt", attempt+1, "error", err, "backoff", backoff)
+		if err := r.deletePartialAsset(ctx, release.GetID(), name); err != nil {
+			return nil, withExitCode(exitUploadFailed, err)
+		}
+		if err := sleepContext(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff = min(backoff*2, maxRetryBackoff)
+	}
+
+	return &assetResult{
+		Name:        asset.GetName(),
+		ID:          asset.GetID(),
+		Size:        int64(len(data)),
+		SHA256:      sha256Hex(data),
+		DownloadURL: asset.GetBrowserDownloadURL(),
+	}, nil
+}
+
+// deletePartialAsset removes whatever asset a failed upload left under name.
+func (r *releaser) deletePartialAsset(ctx context.Context, releaseID int64, name string) error {
+	release, _, err := r.client.Repositories.GetRelease(ctx, r.owner, r.repo, releaseID)
+	if err != nil {
+		return fmt.Errorf("failed to look up partial asset %s: %w", name, err)
+	}
+	return deleteAssetNamed(ctx, r.client, r.owner, r.repo, release, name)
+}
+
+// retryableUpload reports whether an upload failure may be transient: a
+// server error, a 422 caused by a leftover partial asset, a transport error
+// without any response, or a stored asset that failed verification.
+func retryableUpload(err error) bool {
+	var ge *github.ErrorResponse
+	if !errors.As(err, &ge) || ge.Response == nil {
+		return true
+	}
+	status := ge.Response.StatusCode
+	return status >= 500 || status == http.StatusUnprocessableEntity
+}
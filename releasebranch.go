@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/mod/semver"
+)
+
+// releaseBranchData is the data available to -release-branch-template.
+type releaseBranchData struct {
+	ModID   string
+	Version string
+	Major   string
+	Minor   string
+	Patch   string
+}
+
+func newReleaseBranchData(modID, version string) releaseBranchData {
+	// version is normalized, so the core is always major.minor.patch.
+	core := strings.TrimPrefix(semver.Canonical("v"+version), "v")
+	core, _, _ = strings.Cut(core, "-")
+	parts := strings.SplitN(core, ".", 3)
+	return releaseBranchData{ModID: modID, Version: version, Major: parts[0], Minor: parts[1], Patch: parts[2]}
+}
+
+// createReleaseBranch creates the branch named by -release-branch-template
+// at the released commit. An existing branch is left where it is, since it
+// usually already carries backported fixes.
+func (r *releaser) createReleaseBranch(ctx context.Context, pr *pendingRelease) error {
+	name, err := renderTemplate("release branch", r.opts.releaseBranchTemplate, newReleaseBranchData(pr.pkg.mod.ID, pr.version))
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("release branch template produced an empty name")
+	}
+
+	_, _, err = r.client.Git.CreateRef(ctx, r.owner, r.repo, &github.Reference{
+		Ref:    github.String("refs/heads/" + name),
+		Object: &github.GitObject{SHA: github.String(pr.commitSHA)},
+	})
+	if isStatus(err, http.StatusUnprocessableEntity) {
+		slog.Info("Release branch already exists", "branch", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create release branch %s: %w", name, err)
+	}
+	slog.Info("Created release branch", "branch", name, "sha", pr.commitSHA)
+	return nil
+}
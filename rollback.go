@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const rollbackTimeout = 30 * time.Second
+
+// rollback records resources created during a run so they can be removed
+// again if the run is interrupted before it completes.
+type rollback struct {
+	steps []rollbackStep
+}
+
+type rollbackStep struct {
+	desc string
+	undo func(ctx context.Context) error
+}
+
+func (r *rollback) add(desc string, undo func(ctx context.Context) error) {
+	r.steps = append(r.steps, rollbackStep{desc: desc, undo: undo})
+}
+
+// run undoes the recorded steps in reverse order. It uses its own context
+// because the run's context is typically already cancelled at this point.
+func (r *rollback) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	for i := len(r.steps) - 1; i >= 0; i-- {
+		step := r.steps[i]
+		slog.Warn("Rolling back", "step", step.desc)
+		if err := step.undo(ctx); err != nil {
+			slog.Error("Rollback step failed", "step", step.desc, "error", err)
+		}
+	}
+	r.steps = nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// runFilter returns the listing options that restrict workflow runs to
+// -branch and, if set, -event and -actor.
+func (o *options) runFilter(status string) *github.ListWorkflowRunsOptions {
+	return &github.ListWorkflowRunsOptions{Status: status, Branch: o.branch, Event: o.event, Actor: o.actor}
+}
+
+// runMismatch explains why run fails the -event or -actor filter, or
+// returns "" if it passes.
+func (o *options) runMismatch(run *github.WorkflowRun) string {
+	switch {
+	case o.event != "" && run.GetEvent() != o.event:
+		return "event " + run.GetEvent()
+	case o.actor != "" && !strings.EqualFold(run.GetActor().GetLogin(), o.actor):
+		return "actor " + run.GetActor().GetLogin()
+	}
+	return ""
+}
+
+// splitWorkflows splits a comma-separated -workflow value.
+func splitWorkflows(list string) []string {
+	var files []string
+	for _, f := range strings.Split(list, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// listRuns returns the runs matching filter of the first of workflowFiles
+// that has any, together with that workflow's file name. Workflow files that
+// do not exist in the repository are skipped.
+func listRuns(ctx context.Context, client *github.Client, owner, repo string, workflowFiles []string, filter *github.ListWorkflowRunsOptions) (string, []*github.WorkflowRun, error) {
+	for _, workflowFile := range workflowFiles {
+		slog.Debug("Listing workflow runs", "workflow", workflowFile, "branch", filter.Branch, "event", filter.Event, "actor", filter.Actor)
+		runs, _, err := client.Actions.ListWorkflowRunsByFileName(ctx, owner, repo, workflowFile, filter)
+		if isStatus(err, http.StatusNotFound) && len(workflowFiles) > 1 {
+			slog.Debug("Workflow not found, trying the next one", "workflow", workflowFile)
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to list runs of workflow %s: %w", workflowFile, err)
+		}
+		if len(runs.WorkflowRuns) > 0 {
+			return workflowFile, runs.WorkflowRuns, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// findLatestRun returns the most recent run matching filter of the first of
+// workflowFiles that has one.
+func findLatestRun(ctx context.Context, client *github.Client, owner, repo string, workflowFiles []string, filter *github.ListWorkflowRunsOptions) (*github.WorkflowRun, error) {
+	workflowFile, runs, err := listRuns(ctx, client, owner, repo, workflowFiles, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, withExitCode(exitNoRuns, fmt.Errorf("no %s workflow runs found for workflow '%s' on branch '%s'%s", filter.Status, strings.Join(workflowFiles, ","), filter.Branch, filterSuffix(filter)))
+	}
+
+	slog.Debug("Found workflow runs", "workflow", workflowFile, "count", len(runs))
+
+	latestRun := runs[0]
+	slog.Debug("Selected latest run", "run_id", latestRun.GetID(), "head_sha", latestRun.GetHeadSHA(), "created_at", latestRun.GetCreatedAt())
+	return latestRun, nil
+}
+
+// filterSuffix describes the -event and -actor parts of filter for error
+// messages.
+func filterSuffix(filter *github.ListWorkflowRunsOptions) string {
+	var parts []string
+	if filter.Event != "" {
+		parts = append(parts, "event "+filter.Event)
+	}
+	if filter.Actor != "" {
+		parts = append(parts, "actor "+filter.Actor)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// waitForRun polls until the run of workflowFiles for headSHA completes and
+// returns it, failing if it did not succeed.
+func waitForRun(ctx context.Context, client *github.Client, owner, repo string, workflowFiles []string, branch, headSHA string, interval time.Duration) (*github.WorkflowRun, error) {
+	slog.Info("Waiting for workflow run", "workflow", strings.Join(workflowFiles, ","), "head_sha", headSHA)
+	for {
+		_, runs, err := listRuns(ctx, client, owner, repo, workflowFiles, &github.ListWorkflowRunsOptions{
+			Branch:  branch,
+			HeadSHA: headSHA,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(runs) > 0 {
+			run := runs[0]
+			slog.Debug("Workflow run status", "run_id", run.GetID(), "status", run.GetStatus(), "conclusion", run.GetConclusion())
+			if run.GetStatus() == "completed" {
+				if run.GetConclusion() != "success" {
+					return nil, fmt.Errorf("workflow run %d finished with conclusion %q", run.GetID(), run.GetConclusion())
+				}
+				return run, nil
+			}
+		}
+
+		if err := sleepContext(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// getRun fetches a specific workflow run. Runs that are still in progress are
+// accepted, since the tool may be running as a later job of that run, but a
+// completed run must have succeeded.
+func getRun(ctx context.Context, client *github.Client, owner, repo string, runID int64) (*github.WorkflowRun, error) {
+	run, _, err := client.Actions.GetWorkflowRunByID(ctx, owner, repo, runID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow run %d: %w", runID, err)
+	}
+	if run.GetStatus() == "completed" && run.GetConclusion() != "success" {
+		return nil, fmt.Errorf("workflow run %d finished with conclusion %q", runID, run.GetConclusion())
+	}
+	slog.Debug("Selected run", "run_id", run.GetID(), "head_sha", run.GetHeadSHA(), "status", run.GetStatus())
+	return run, nil
+}
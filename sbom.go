@@ -0,0 +1,263 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SBOM formats accepted by -sbom.
+const (
+	sbomCycloneDX = "cyclonedx"
+	sbomSPDX      = "spdx"
+)
+
+// sbomFile is a file inside a .geode package.
+type sbomFile struct {
+	name   string
+	sha1   string
+	sha256 string
+}
+
+// packageFiles hashes every file in pkg for the SBOM.
+func packageFiles(pkg *geodePackage) ([]sbomFile, error) {
+	r, err := zip.NewReader(bytes.NewReader(pkg.data), int64(len(pkg.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+	var files []sbomFile
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s inside .geode: %w", f.Name, err)
+		}
+		s1 := sha1.Sum(data)
+		files = append(files, sbomFile{name: f.Name, sha1: hex.EncodeToString(s1[:]), sha256: sha256Hex(data)})
+	}
+	return files, nil
+}
+
+// sbomAsset builds the SBOM of pkg released as t in the -sbom format: the
+// files in the package and the dependencies declared in its mod.json.
+func (r *releaser) sbomAsset(pkg *geodePackage, t *releaseTarget) (extraAsset, error) {
+	files, err := packageFiles(pkg)
+	if err != nil {
+		return extraAsset{}, err
+	}
+	deps, err := decodeDependencies(pkg.mod.Dependencies)
+	if err != nil {
+		return extraAsset{}, fmt.Errorf("failed to read dependencies: %w", err)
+	}
+
+	base := strings.TrimSuffix(t.assetName, ".geode")
+	var doc any
+	var name string
+	switch r.opts.sbom {
+	case sbomSPDX:
+		name = base + ".spdx.json"
+		namespace := fmt.Sprintf("https://github.com/%s/%s/releases/%s/%s", r.owner, r.repo, t.tag, name)
+		doc = newSPDXDocument(pkg, t.version, namespace, files, deps)
+	default:
+		name = base + ".cdx.json"
+		doc = newCycloneDXBOM(pkg, t.version, files, deps)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return extraAsset{}, fmt.Errorf("failed to encode SBOM: %w", err)
+	}
+	return extraAsset{name: name, data: append(data, '\n')}, nil
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxComponent struct {
+	Type        string            `json:"type"`
+	BOMRef      string            `json:"bom-ref"`
+	Name        string            `json:"name"`
+	Version     string            `json:"version,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Scope       string            `json:"scope,omitempty"`
+	Authors     []cdxOrganization `json:"authors,omitempty"`
+	Hashes      []cdxHash         `json:"hashes,omitempty"`
+}
+
+type cdxOrganization struct {
+	Name string `json:"name"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+type cdxBOM struct {
+	BOMFormat    string `json:"bomFormat"`
+	SpecVersion  string `json:"specVersion"`
+	SerialNumber string `json:"serialNumber"`
+	Version      int    `json:"version"`
+	Metadata     struct {
+		Tools struct {
+			Components []cdxComponent `json:"components"`
+		} `json:"tools"`
+		Component cdxComponent `json:"component"`
+	} `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+// newCycloneDXBOM describes pkg as a CycloneDX 1.5 BOM. The serial number
+// is derived from the package digest so the same package always yields the
+// same BOM.
+func newCycloneDXBOM(pkg *geodePackage, version string, files []sbomFile, deps []modDependency) *cdxBOM {
+	digest := sha256Hex(pkg.data)
+	bom := &cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + digestUUID(digest),
+		Version:      1,
+	}
+	bom.Metadata.Tools.Components = []cdxComponent{{Type: "application", BOMRef: "gwtreleaser", Name: "gwtreleaser"}}
+	mod := cdxComponent{
+		Type:        "application",
+		BOMRef:      pkg.mod.ID,
+		Name:        pkg.mod.ID,
+		Version:     version,
+		Description: pkg.mod.Description,
+		Hashes:      []cdxHash{{Alg: "SHA-256", Content: digest}},
+	}
+	for _, dev := range pkg.mod.developers() {
+		mod.Authors = append(mod.Authors, cdxOrganization{Name: dev})
+	}
+	bom.Metadata.Component = mod
+
+	root := cdxDependency{Ref: pkg.mod.ID}
+	for _, f := range files {
+		bom.Components = append(bom.Components, cdxComponent{
+			Type:   "file",
+			BOMRef: "file:" + f.name,
+			Name:   f.name,
+			Hashes: []cdxHash{{Alg: "SHA-1", Content: f.sha1}, {Alg: "SHA-256", Content: f.sha256}},
+		})
+	}
+	for _, d := range deps {
+		scope := "required"
+		if d.Importance != "" && d.Importance != "required" {
+			scope = "optional"
+		}
+		bom.Components = append(bom.Components, cdxComponent{Type: "library", BOMRef: d.ID, Name: d.ID, Version: d.Version, Scope: scope})
+		root.DependsOn = append(root.DependsOn, d.ID)
+	}
+	bom.Dependencies = []cdxDependency{root}
+	return bom
+}
+
+// digestUUID formats the start of a hex digest as a version 4 UUID.
+func digestUUID(digest string) string {
+	b, _ := hex.DecodeString(digest[:32])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxPackage struct {
+	Name             string         `json:"name"`
+	SPDXID           string         `json:"SPDXID"`
+	VersionInfo      string         `json:"versionInfo,omitempty"`
+	Supplier         string         `json:"supplier,omitempty"`
+	DownloadLocation string         `json:"downloadLocation"`
+	FilesAnalyzed    bool           `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum `json:"checksums,omitempty"`
+	Description      string         `json:"description,omitempty"`
+}
+
+type spdxFile struct {
+	FileName  string         `json:"fileName"`
+	SPDXID    string         `json:"SPDXID"`
+	Checksums []spdxChecksum `json:"checksums"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+type spdxDocument struct {
+	SPDXVersion       string `json:"spdxVersion"`
+	DataLicense       string `json:"dataLicense"`
+	SPDXID            string `json:"SPDXID"`
+	Name              string `json:"name"`
+	DocumentNamespace string `json:"documentNamespace"`
+	CreationInfo      struct {
+		Created  string   `json:"created"`
+		Creators []string `json:"creators"`
+	} `json:"creationInfo"`
+	Packages      []spdxPackage      `json:"packages"`
+	Files         []spdxFile         `json:"files"`
+	Relationships []spdxRelationship `json:"relationships"`
+}
+
+// newSPDXDocument describes pkg as an SPDX 2.3 document whose package
+// contains the files of the .geode and depends on its mod.json
+// dependencies.
+func newSPDXDocument(pkg *geodePackage, version, namespace string, files []sbomFile, deps []modDependency) *spdxDocument {
+	doc := &spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              pkg.mod.ID + "-" + version,
+		DocumentNamespace: namespace,
+	}
+	doc.CreationInfo.Created = time.Now().UTC().Format(time.RFC3339)
+	doc.CreationInfo.Creators = []string{"Tool: gwtreleaser"}
+
+	mod := spdxPackage{
+		Name:             pkg.mod.ID,
+		SPDXID:           "SPDXRef-Package",
+		VersionInfo:      version,
+		DownloadLocation: "NOASSERTION",
+		Checksums:        []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: sha256Hex(pkg.data)}},
+		Description:      pkg.mod.Description,
+	}
+	if devs := pkg.mod.developers(); len(devs) > 0 {
+		mod.Supplier = "Person: " + strings.Join(devs, ", ")
+	}
+	doc.Packages = []spdxPackage{mod}
+	doc.Relationships = []spdxRelationship{{Element: doc.SPDXID, Type: "DESCRIBES", Related: mod.SPDXID}}
+
+	for i, f := range files {
+		id := fmt.Sprintf("SPDXRef-File-%d", i+1)
+		doc.Files = append(doc.Files, spdxFile{
+			FileName:  "./" + f.name,
+			SPDXID:    id,
+			Checksums: []spdxChecksum{{Algorithm: "SHA1", ChecksumValue: f.sha1}, {Algorithm: "SHA256", ChecksumValue: f.sha256}},
+		})
+		doc.Relationships = append(doc.Relationships, spdxRelationship{Element: mod.SPDXID, Type: "CONTAINS", Related: id})
+	}
+	for i, d := range deps {
+		id := fmt.Sprintf("SPDXRef-Dependency-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPackage{Name: d.ID, SPDXID: id, VersionInfo: d.Version, DownloadLocation: "NOASSERTION"})
+		rel := spdxRelationship{Element: mod.SPDXID, Type: "DEPENDS_ON", Related: id}
+		if d.Importance != "" && d.Importance != "required" {
+			rel = spdxRelationship{Element: id, Type: "OPTIONAL_DEPENDENCY_OF", Related: mod.SPDXID}
+		}
+		doc.Relationships = append(doc.Relationships, rel)
+	}
+	return doc
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// scanMain implements "scan": it looks through every repository of an
+// organization for a successful build of the workflow that is not released
+// yet, and releases it.
+func scanMain(args []string) int {
+	opts := options{multiRepo: true}
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	opts.register(fs)
+	org := fs.String("org", "", "Organization whose repositories to scan (required)")
+	topic := fs.String("topic", "", "Only scan repositories with this topic")
+	dryRun := fs.Bool("dry-run", false, "Report what would be released without releasing it")
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *org == "" {
+		fmt.Fprintln(os.Stderr, "-org is required")
+		return exitUsage
+	}
+	if opts.runID != 0 || opts.file != "" || opts.buildCmd != "" || opts.bump != "" || opts.interactive {
+		fmt.Fprintln(os.Stderr, "-run-id, -file, -build-cmd, -bump and -interactive cannot be used with scan")
+		return exitUsage
+	}
+
+	// Without a -branch setting each repository's default branch is used.
+	branchSet := false
+	fs.Visit(func(f *flag.Flag) { branchSet = branchSet || f.Name == "branch" })
+	if !branchSet {
+		opts.branch = ""
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	opts.owner = *org
+	r, err := newReleaser(ctx, &opts)
+	var repos []*github.Repository
+	if err == nil {
+		repos, err = orgRepositories(ctx, r.client, *org, *topic)
+	}
+	if err != nil {
+		code := exitCodeOf(err)
+		slog.Error("Scan failed", "error", err, "exit_code", code)
+		return code
+	}
+	slog.Info("Scanning repositories", "org", *org, "topic", *topic, "count", len(repos))
+
+	var results []batchResult
+	code := 0
+	for _, repo := range repos {
+		br, err := scanRepository(ctx, opts, repo, *dryRun)
+		if br == nil {
+			continue
+		}
+		if err != nil {
+			slog.Error("Release failed", "repository", br.Repository, "error", err)
+			br.Error = err.Error()
+			code = max(code, exitCodeOf(err))
+		}
+		results = append(results, *br)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if err := writeBatchResults(os.Stdout, opts.outputFormat, results); err != nil {
+		slog.Error("Failed to write result", "error", err)
+		return exitFailure
+	}
+	return code
+}
+
+// orgRepositories lists the unarchived repositories of org, only those
+// tagged with topic if it is set.
+func orgRepositories(ctx context.Context, client *github.Client, org, topic string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	listOpts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, org, listOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", org, err)
+		}
+		for _, repo := range page {
+			if repo.GetArchived() || (topic != "" && !slices.Contains(repo.Topics, topic)) {
+				continue
+			}
+			repos = append(repos, repo)
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// scanRepository releases the latest successful build of repo on its
+// default branch, or -branch if set, if it is not released yet. It returns
+// nil for repositories without the workflow or without a successful build
+// of it.
+func scanRepository(ctx context.Context, opts options, repo *github.Repository, dryRun bool) (*batchResult, error) {
+	opts.owner, opts.repo = repo.GetOwner().GetLogin(), repo.GetName()
+	opts.artifactOwner, opts.artifactRepo = opts.owner, opts.repo
+	if opts.branch == "" {
+		opts.branch = repo.GetDefaultBranch()
+	}
+	br := &batchResult{Repository: repo.GetFullName()}
+
+	r, err := newReleaser(ctx, &opts)
+	if err != nil {
+		return br, err
+	}
+	st, err := r.status(ctx)
+	switch {
+	case exitCodeOf(err) == exitNoRuns || isStatus(err, http.StatusNotFound):
+		slog.Debug("No successful build to release", "repository", br.Repository, "error", err)
+		return nil, nil
+	case err != nil:
+		return br, err
+	case !st.Pending:
+		slog.Info("Up to date", "repository", br.Repository, "run_id", st.RunID)
+		br.Result = &runResult{RunID: st.RunID}
+		return br, nil
+	}
+
+	if dryRun {
+		for _, p := range st.Packages {
+			if !p.Released {
+				br.Pending = append(br.Pending, p.Tag)
+			}
+		}
+		return br, nil
+	}
+	opts.runID = st.RunID
+	br.Result, err = run(ctx, &opts)
+	return br, err
+}
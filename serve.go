@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// webhookServer accepts workflow_run deliveries and queues the matching
+// runs for release.
+type webhookServer struct {
+	opts   *options
+	secret []byte
+	queue  chan int64
+}
+
+// serveMain implements "serve": it listens for GitHub workflow_run webhooks
+// and releases each successful run of the configured workflow and branch.
+// Releases are performed one at a time in delivery order.
+func serveMain(args []string) int {
+	var opts options
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	opts.register(flags)
+	listen := flags.String("listen", ":8080", "Address to accept webhook deliveries on")
+	if err := opts.parse(flags, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if opts.bump != "" || opts.runID != 0 || opts.file != "" || opts.buildCmd != "" || opts.interactive {
+		fmt.Fprintln(os.Stderr, "-bump, -run-id, -file, -build-cmd and -interactive cannot be used with serve")
+		return exitUsage
+	}
+	secret := os.Getenv("GWTRELEASER_WEBHOOK_SECRET")
+	if secret == "" {
+		fmt.Fprintln(os.Stderr, "GWTRELEASER_WEBHOOK_SECRET environment variable must be set to validate deliveries")
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	if err != nil {
+		slog.Error("Serve failed", "error", err)
+		return exitCodeOf(err)
+	}
+
+	s := &webhookServer{opts: &opts, secret: []byte(secret), queue: make(chan int64, 16)}
+	srv := &http.Server{
+		Addr:              *listen,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case runID := <-s.queue:
+				r.releaseRun(ctx, runID)
+			}
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Listening for workflow_run webhooks", "addr", *listen, "workflow", opts.workflowFile, "branch", opts.branch)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Serve failed", "error", err)
+		return exitFailure
+	}
+	return 0
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	payload, err := github.ValidatePayload(req, s.secret)
+	if err != nil {
+		slog.Warn("Rejected webhook delivery", "remote", req.RemoteAddr, "error", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := github.ParseWebHook(github.WebHookType(req), payload)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ev, ok := event.(*github.WorkflowRunEvent)
+	if !ok {
+		// Also covers the ping sent when the webhook is created.
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	run := ev.GetWorkflowRun()
+	if reason := s.skipReason(ev); reason != "" {
+		slog.Debug("Ignoring workflow_run delivery", "run_id", run.GetID(), "reason", reason)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	select {
+	case s.queue <- run.GetID():
+		slog.Info("Queued workflow run for release", "run_id", run.GetID())
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, "release queue full", http.StatusServiceUnavailable)
+	}
+}
+
+// skipReason explains why ev does not trigger a release, or returns "" if
+// it should.
+func (s *webhookServer) skipReason(ev *github.WorkflowRunEvent) string {
+	run := ev.GetWorkflowRun()
+	switch {
+	case ev.GetAction() != "completed":
+		return "action " + ev.GetAction()
+	case run.GetConclusion() != "success":
+		return "conclusion " + run.GetConclusion()
+	case !strings.EqualFold(ev.GetRepo().GetFullName(), s.opts.artifactOwner+"/"+s.opts.artifactRepo):
+		return "repository " + ev.GetRepo().GetFullName()
+	case !slices.Contains(s.opts.workflowFiles, path.Base(ev.GetWorkflow().GetPath())):
+		return "workflow " + ev.GetWorkflow().GetPath()
+	case run.GetHeadBranch() != s.opts.branch:
+		return "branch " + run.GetHeadBranch()
+	case s.opts.runMismatch(run) != "":
+		return s.opts.runMismatch(run)
+	}
+	return ""
+}
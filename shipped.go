@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// shippedIssues returns the issues completed and the pull requests merged
+// since the previous release was published. Pull requests are left out with
+// -comment-prs, which comments on them itself.
+func (r *releaser) shippedIssues(ctx context.Context, since time.Time) ([]*github.Issue, error) {
+	repo := fmt.Sprintf("repo:%s/%s", r.owner, r.repo)
+	stamp := since.UTC().Format(time.RFC3339)
+	queries := []string{fmt.Sprintf("%s is:issue is:closed reason:completed closed:>%s", repo, stamp)}
+	if !r.opts.commentPRs {
+		queries = append(queries, fmt.Sprintf("%s is:pr is:merged merged:>%s", repo, stamp))
+	}
+
+	var issues []*github.Issue
+	for _, q := range queries {
+		opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			res, resp, err := r.client.Search.Issues(ctx, q, opts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search issues: %w", err)
+			}
+			issues = append(issues, res.Issues...)
+			if resp.NextPage == 0 {
+				break
+			}
+			opts.Page = resp.NextPage
+		}
+	}
+	return issues, nil
+}
+
+// commentShipped tells the issues and pull requests that landed since prev
+// which release they shipped in, and labels them if -issue-label is set.
+func (r *releaser) commentShipped(ctx context.Context, pr *pendingRelease, prev *github.RepositoryRelease) error {
+	issues, err := r.shippedIssues(ctx, prev.GetPublishedAt().Time)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("This was released in [%s](%s).", pr.tag, pr.release.GetHTMLURL())
+	for _, issue := range issues {
+		n := issue.GetNumber()
+		if _, _, err := r.client.Issues.CreateComment(ctx, r.owner, r.repo, n, &github.IssueComment{Body: github.String(body)}); err != nil {
+			return fmt.Errorf("failed to comment on #%d: %w", n, err)
+		}
+		if r.opts.issueLabel != "" {
+			if _, _, err := r.client.Issues.AddLabelsToIssue(ctx, r.owner, r.repo, n, []string{r.opts.issueLabel}); err != nil {
+				return fmt.Errorf("failed to label #%d: %w", n, err)
+			}
+		}
+		slog.Debug("Commented on shipped issue", "number", n)
+	}
+	slog.Info("Commented on shipped issues", "tag", pr.tag, "count", len(issues))
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// checkSizeGrowth compares the package of pr with the one in the previous
+// release and fails when it grew by more than -max-growth percent or
+// -max-growth-bytes, unless -size-warn is set. There is nothing to compare
+// against for a first release.
+func (r *releaser) checkSizeGrowth(ctx context.Context, pr *pendingRelease) error {
+	prev, old, err := r.previousPackageOf(ctx, pr)
+	if err != nil || old == nil {
+		return err
+	}
+
+	before, after := int64(len(old.data)), int64(len(pr.pkg.data))
+	growth := after - before
+	percent := 100 * float64(growth) / float64(max(before, 1))
+	var exceeded []string
+	if r.opts.maxGrowth > 0 && percent > r.opts.maxGrowth {
+		exceeded = append(exceeded, fmt.Sprintf("%.1f%% > %g%%", percent, r.opts.maxGrowth))
+	}
+	if r.opts.maxGrowthBytes > 0 && growth > r.opts.maxGrowthBytes {
+		exceeded = append(exceeded, fmt.Sprintf("%s > %s", formatBytes(growth), formatBytes(r.opts.maxGrowthBytes)))
+	}
+	if len(exceeded) == 0 {
+		slog.Debug("Package size within limits", "file", pr.pkg.filename, "previous", prev.GetTagName(), "bytes", after, "growth_bytes", growth)
+		return nil
+	}
+
+	msg := fmt.Sprintf("%s grew from %s in %s to %s (%s)", pr.pkg.filename, formatBytes(before), prev.GetTagName(), formatBytes(after), strings.Join(exceeded, ", "))
+	if r.opts.sizeWarn {
+		slog.Warn("Package size grew beyond the limit", "detail", msg)
+		return nil
+	}
+	return fmt.Errorf("%s; use -size-warn to release anyway", msg)
+}
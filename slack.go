@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+const defaultSlackTemplate = `*{{.ModName}} {{.Tag}}* released in {{.Repo}}: <{{.ReleaseURL}}|release notes>{{if .DownloadURL}} · <{{.DownloadURL}}|download>{{end}}`
+
+// slackConfig selects how releases are posted to Slack: through an incoming
+// webhook, or through chat.postMessage with a bot token and channel.
+type slackConfig struct {
+	webhookURL string
+	botToken   string
+	channel    string
+	template   string
+}
+
+func (c *slackConfig) enabled() bool {
+	return c.webhookURL != "" || c.botToken != ""
+}
+
+// notifySlack renders the message template for a and posts it.
+func notifySlack(ctx context.Context, client *http.Client, cfg *slackConfig, a *announcement) error {
+	text, err := renderTemplate("slack", cfg.template, a)
+	if err != nil {
+		return err
+	}
+
+	if cfg.webhookURL != "" {
+		return postJSON(ctx, client, cfg.webhookURL, map[string]string{"text": text}, nil)
+	}
+	if cfg.channel == "" {
+		return errors.New("a Slack channel is required when posting with a bot token")
+	}
+
+	body, err := json.Marshal(map[string]string{"channel": cfg.channel, "text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, slackPostMessageURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+cfg.botToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// chat.postMessage reports failures in the body with a 200 status.
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode Slack response (%s): %w", resp.Status, err)
+	}
+	if !result.OK {
+		return fmt.Errorf("slack rejected the message: %s", result.Error)
+	}
+	return nil
+}
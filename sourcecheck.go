@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// checkSourceVersion compares the version packaged in pkg with the mod.json
+// in the repository at sha, the commit the build ran on, catching builds made
+// from stale sources. For monorepos a source mod.json of a different mod is
+// skipped.
+func (r *releaser) checkSourceVersion(ctx context.Context, pkg *geodePackage, sha string) error {
+	content, err := repoFile(ctx, r.client, r.owner, r.repo, r.opts.repoModJSON, sha)
+	if err != nil {
+		return err
+	}
+	if content == "" {
+		return fmt.Errorf("%s not found in the repository at %.7s", r.opts.repoModJSON, sha)
+	}
+
+	var src ModJSON
+	if err := json.Unmarshal([]byte(content), &src); err != nil {
+		return fmt.Errorf("failed to parse %s at %.7s: %w", r.opts.repoModJSON, sha, err)
+	}
+	if src.ID != pkg.mod.ID {
+		slog.Debug("Source mod.json belongs to another mod, skipping version check", "path", r.opts.repoModJSON, "id", src.ID, "package", pkg.mod.ID)
+		return nil
+	}
+
+	built, err := normalizeVersion(pkg.mod.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version in packaged mod.json: %w", err)
+	}
+	source, err := normalizeVersion(src.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version in %s: %w", r.opts.repoModJSON, err)
+	}
+	if built != source {
+		return fmt.Errorf("%s is packaged as version %s but %s at %.7s says %s; the build may be from stale sources", pkg.mod.ID, built, r.opts.repoModJSON, sha, source)
+	}
+	slog.Debug("Packaged version matches the source", "mod_id", pkg.mod.ID, "version", built, "sha", sha)
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+)
+
+// platformGroup is one -split-platforms variant: the platforms whose
+// binaries it keeps, named after the list entry that selected them.
+type platformGroup struct {
+	name      string
+	platforms []string
+}
+
+// parsePlatformGroups parses -split-platforms. Each comma-separated entry,
+// such as "win" or "android", becomes one variant.
+func parsePlatformGroups(list string) ([]platformGroup, error) {
+	var groups []platformGroup
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		platforms, err := parsePlatforms(entry)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, platformGroup{name: entry, platforms: platforms})
+	}
+	return groups, nil
+}
+
+// platformVariants builds a stripped copy of pkg for each group that keeps
+// only that group's binaries, named after assetName with the group as a
+// suffix. Packages shipping a single platform are not split, and groups the
+// package has no binary for are skipped.
+func platformVariants(pkg *geodePackage, assetName string, groups []platformGroup) ([]extraAsset, error) {
+	present, err := packagePlatforms(pkg)
+	if err != nil {
+		return nil, err
+	}
+	if len(present) < 2 {
+		slog.Debug("Package ships a single platform, not splitting it", "file", pkg.filename, "platforms", present)
+		return nil, nil
+	}
+
+	base := strings.TrimSuffix(assetName, ".geode")
+	var variants []extraAsset
+	for _, g := range groups {
+		if !slices.ContainsFunc(g.platforms, func(p string) bool { return slices.Contains(present, p) }) {
+			slog.Info("Package has no binaries for platform variant, skipping it", "file", pkg.filename, "variant", g.name)
+			continue
+		}
+		data, err := stripPlatforms(pkg, g.platforms)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s variant: %w", g.name, err)
+		}
+		slog.Debug("Built platform variant", "file", pkg.filename, "variant", g.name, "bytes", len(data))
+		variants = append(variants, extraAsset{name: base + "-" + g.name + ".geode", data: data})
+	}
+	return variants, nil
+}
+
+// stripPlatforms copies pkg without the binaries of platforms other than
+// keep. Entries are copied without recompressing them.
+func stripPlatforms(pkg *geodePackage, keep []string) ([]byte, error) {
+	r, err := zip.NewReader(bytes.NewReader(pkg.data), int64(len(pkg.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+
+	drop := make(map[string]bool)
+	for _, p := range platformOrder {
+		if !slices.Contains(keep, p) {
+			drop[pkg.mod.ID+platformBinaries[p]] = true
+		}
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		if drop[path.Base(f.Name)] {
+			continue
+		}
+		if err := w.Copy(f); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", f.Name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergePackages combines two builds of the same mod version like geode
+// package merge: a is kept whole and b only contributes the platform
+// binaries a lacks.
+func mergePackages(a, b *geodePackage) (*geodePackage, error) {
+	if a.mod.Version != b.mod.Version {
+		return nil, fmt.Errorf("versions differ, %s and %s", a.mod.Version, b.mod.Version)
+	}
+	ar, err := zip.NewReader(bytes.NewReader(a.data), int64(len(a.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s as zip: %w", a.filename, err)
+	}
+	br, err := zip.NewReader(bytes.NewReader(b.data), int64(len(b.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s as zip: %w", b.filename, err)
+	}
+
+	binaries := make(map[string]bool)
+	for _, p := range platformOrder {
+		binaries[a.mod.ID+platformBinaries[p]] = true
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	have := make(map[string]bool)
+	for _, f := range ar.File {
+		have[f.Name] = true
+		if err := w.Copy(f); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", f.Name, err)
+		}
+	}
+	for _, f := range br.File {
+		if have[f.Name] || !binaries[path.Base(f.Name)] {
+			continue
+		}
+		if err := w.Copy(f); err != nil {
+			return nil, fmt.Errorf("failed to copy %s: %w", f.Name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &geodePackage{filename: a.filename, data: buf.Bytes(), mod: a.mod, built: sha256Hex(buf.Bytes())}, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// testZip builds a zip of the given files, in order, as name and content
+// pairs.
+func testZip(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		fw, err := w.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(files[i+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipContents returns the files of a zip by name.
+func zipContents(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range r.File {
+		b, err := readZipFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(b)
+	}
+	return files
+}
+
+func TestParsePlatformGroups(t *testing.T) {
+	groups, err := parsePlatformGroups(" win, android ,,mac")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []platformGroup{
+		{"win", []string{"windows"}},
+		{"android", []string{"android32", "android64"}},
+		{"mac", []string{"macos"}},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("parsePlatformGroups() = %v, want %v", groups, want)
+	}
+	for i := range want {
+		if groups[i].name != want[i].name || !slices.Equal(groups[i].platforms, want[i].platforms) {
+			t.Errorf("group %d = %v, want %v", i, groups[i], want[i])
+		}
+	}
+
+	if _, err := parsePlatformGroups("win,amiga"); err == nil {
+		t.Error("parsePlatformGroups accepted an unknown platform")
+	}
+}
+
+func TestStripPlatforms(t *testing.T) {
+	pkg := &geodePackage{
+		filename: "my.mod.geode",
+		mod:      &ModJSON{ID: "my.mod", Version: "1.0.0"},
+		data: testZip(t,
+			"mod.json", "{}",
+			"my.mod.dll", "win",
+			"my.mod.dylib", "mac",
+			"my.mod.ios.dylib", "ios",
+			"my.mod.android32.so", "a32",
+			"my.mod.android64.so", "a64",
+			"resources/logo.png", "png",
+			"other.mod.dll", "dependency",
+		),
+	}
+
+	tests := []struct {
+		name string
+		keep []string
+		want []string
+	}{
+		{"windows", []string{"windows"}, []string{"mod.json", "my.mod.dll", "other.mod.dll", "resources/logo.png"}},
+		{"android", []string{"android32", "android64"}, []string{"mod.json", "my.mod.android32.so", "my.mod.android64.so", "other.mod.dll", "resources/logo.png"}},
+		{"none", nil, []string{"mod.json", "other.mod.dll", "resources/logo.png"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := stripPlatforms(pkg, tt.keep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files := zipContents(t, data)
+			if got := slices.Sorted(maps.Keys(files)); !slices.Equal(got, tt.want) {
+				t.Errorf("stripPlatforms() kept %v, want %v", got, tt.want)
+			}
+			if files["resources/logo.png"] != "png" {
+				t.Errorf("stripPlatforms() changed the contents of kept files")
+			}
+		})
+	}
+}
+
+func TestPlatformVariants(t *testing.T) {
+	mod := &ModJSON{ID: "my.mod", Version: "1.0.0"}
+	groups := []platformGroup{{"win", []string{"windows"}}, {"mac", []string{"macos"}}, {"android", []string{"android32", "android64"}}}
+
+	multi := &geodePackage{filename: "my.mod.geode", mod: mod, data: testZip(t, "mod.json", "{}", "my.mod.dll", "win", "my.mod.android64.so", "a64")}
+	variants, err := platformVariants(multi, "my.mod-1.0.0.geode", groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range variants {
+		names = append(names, v.name)
+	}
+	// There is no macOS binary, so no mac variant.
+	if want := []string{"my.mod-1.0.0-win.geode", "my.mod-1.0.0-android.geode"}; !slices.Equal(names, want) {
+		t.Errorf("platformVariants() = %v, want %v", names, want)
+	}
+
+	single := &geodePackage{filename: "my.mod.geode", mod: mod, data: testZip(t, "mod.json", "{}", "my.mod.dll", "win")}
+	if variants, err := platformVariants(single, "my.mod.geode", groups); err != nil || len(variants) != 0 {
+		t.Errorf("platformVariants() of a single-platform package = %v, %v, want none", variants, err)
+	}
+}
+
+func TestMergePackages(t *testing.T) {
+	mod := &ModJSON{ID: "my.mod", Version: "1.0.0"}
+	win := &geodePackage{filename: "my.mod.geode", mod: mod, data: testZip(t,
+		"mod.json", "windows build",
+		"my.mod.dll", "win",
+		"resources/logo.png", "png",
+	)}
+	android := &geodePackage{filename: "android/my.mod.geode", mod: mod, data: testZip(t,
+		"mod.json", "android build",
+		"my.mod.android64.so", "a64",
+		"my.mod.dll", "stale win",
+		"resources/extra.png", "extra",
+	)}
+
+	merged, err := mergePackages(win, android)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := zipContents(t, merged.data)
+	want := map[string]string{
+		"mod.json":            "windows build",
+		"my.mod.dll":          "win",
+		"my.mod.android64.so": "a64",
+		"resources/logo.png":  "png",
+	}
+	if !maps.Equal(files, want) {
+		t.Errorf("mergePackages() = %v, want %v", files, want)
+	}
+	if merged.filename != win.filename || merged.built != sha256Hex(merged.data) {
+		t.Errorf("mergePackages() = %s with built digest %s, want %s with the digest of its data", merged.filename, merged.built, win.filename)
+	}
+
+	other := &geodePackage{filename: "old.geode", mod: &ModJSON{ID: "my.mod", Version: "0.9.0"}, data: android.data}
+	if _, err := mergePackages(win, other); err == nil {
+		t.Error("mergePackages() merged different versions")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// Stamp targets accepted by -stamp.
+const (
+	stampBuildInfo = "build-info"
+	stampModJSON   = "mod-json"
+)
+
+const buildInfoFile = "build-info.json"
+
+// buildInfo records where a package was built.
+type buildInfo struct {
+	Repository string `json:"repository"`
+	Commit     string `json:"commit"`
+	RunID      int64  `json:"run_id,omitempty"`
+	RunURL     string `json:"run_url,omitempty"`
+	BuiltAt    string `json:"built_at"`
+}
+
+// newBuildInfo describes a build of commit by run, which is nil for local
+// packages, committed at committedAt. The run's completion time, or for
+// local packages the commit time, is used rather than the current time so
+// that stamping the same build twice gives the same package.
+func newBuildInfo(owner, repo, commit string, committedAt time.Time, run *github.WorkflowRun) *buildInfo {
+	info := &buildInfo{Repository: owner + "/" + repo, Commit: commit, BuiltAt: committedAt.UTC().Format(time.RFC3339)}
+	if run != nil {
+		info.Commit = run.GetHeadSHA()
+		info.RunID = run.GetID()
+		info.RunURL = run.GetHTMLURL()
+		info.BuiltAt = run.GetUpdatedAt().UTC().Format(time.RFC3339)
+	}
+	return info
+}
+
+// stampPackage rewrites pkg with info, either as a build-info.json next to
+// mod.json or as a "build-info" object in mod.json itself. The mod.json is
+// the one readModJSON reads, so -mod-json-path and nested ones are honored.
+func stampPackage(pkg *geodePackage, info *buildInfo, target string) error {
+	stamp, err := json.MarshalIndent(info, "", "\t")
+	if err != nil {
+		return fmt.Errorf("failed to encode build info: %w", err)
+	}
+	builtAt, _ := time.Parse(time.RFC3339, info.BuiltAt)
+
+	r, err := zip.NewReader(bytes.NewReader(pkg.data), int64(len(pkg.data)))
+	if err != nil {
+		return fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+	modJSON, err := findModJSON(r)
+	if err != nil {
+		return err
+	}
+	infoName := path.Join(path.Dir(modJSON.Name), buildInfoFile)
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	write := func(name string, data []byte) error {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: builtAt})
+		if err != nil {
+			return err
+		}
+		_, err = fw.Write(data)
+		return err
+	}
+
+	for _, f := range r.File {
+		switch {
+		case f.Name == infoName && target == stampBuildInfo:
+			// Replaced below.
+			continue
+		case f == modJSON && target == stampModJSON:
+			raw, err := readZipFile(f)
+			if err != nil {
+				return fmt.Errorf("failed to read mod.json: %w", err)
+			}
+			raw, err = addModJSONField(raw, "build-info", stamp)
+			if err != nil {
+				return err
+			}
+			if err := write(f.Name, raw); err != nil {
+				return fmt.Errorf("failed to write mod.json: %w", err)
+			}
+			continue
+		}
+		if err := w.Copy(f); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", f.Name, err)
+		}
+	}
+	if target == stampBuildInfo {
+		if err := write(infoName, append(stamp, '\n')); err != nil {
+			return fmt.Errorf("failed to write %s: %w", infoName, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	pkg.data = buf.Bytes()
+	slog.Info("Stamped build info into package", "file", pkg.filename, "target", target, "commit", info.Commit, "run_id", info.RunID)
+	return nil
+}
+
+// addModJSONField adds the top-level key with the JSON value to mod.json,
+// replacing an existing one. The rest of the file keeps its formatting
+// unless the key has to be replaced.
+func addModJSONField(raw []byte, key string, value []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse mod.json: %w", err)
+	}
+	if _, ok := fields[key]; ok {
+		fields[key] = value
+		return json.MarshalIndent(fields, "", "\t")
+	}
+
+	end := bytes.LastIndexByte(raw, '}')
+	if end < 0 {
+		return nil, errors.New("mod.json is not an object")
+	}
+	body := bytes.TrimRight(raw[:end], " \t\r\n")
+	var out bytes.Buffer
+	out.Write(body)
+	if len(fields) > 0 {
+		out.WriteByte(',')
+	}
+	fmt.Fprintf(&out, "\n\t%q: %s\n}\n", key, bytes.ReplaceAll(value, []byte("\n"), []byte("\n\t")))
+	return out.Bytes(), nil
+}
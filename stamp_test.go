@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAddModJSONField(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "appends keeping formatting",
+			raw:  "{\n    \"id\": \"my.mod\",\n    \"version\": \"1.0.0\"\n}\n",
+			want: "{\n    \"id\": \"my.mod\",\n    \"version\": \"1.0.0\",\n\t\"build-info\": {\n\t\t\"commit\": \"abc\"\n\t}\n}\n",
+		},
+		{
+			name: "empty object",
+			raw:  "{}",
+			want: "{\n\t\"build-info\": {\n\t\t\"commit\": \"abc\"\n\t}\n}\n",
+		},
+		{
+			name: "replaces an existing key",
+			raw:  `{"build-info": {"commit": "old"}, "id": "my.mod"}`,
+			want: "{\n\t\"build-info\": {\n\t\t\"commit\": \"abc\"\n\t},\n\t\"id\": \"my.mod\"\n}",
+		},
+		{name: "not an object", raw: `["id"]`, wantErr: true},
+		{name: "invalid", raw: `{"id": `, wantErr: true},
+	}
+	value := []byte("{\n\t\"commit\": \"abc\"\n}")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addModJSONField([]byte(tt.raw), "build-info", value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("addModJSONField() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(got) != tt.want {
+				t.Errorf("addModJSONField() = %q, want %q", got, tt.want)
+			}
+			if !json.Valid(got) {
+				t.Errorf("addModJSONField() produced invalid JSON: %s", got)
+			}
+		})
+	}
+}
+
+func TestStampPackage(t *testing.T) {
+	defer func(p string) { modJSONPath = p }(modJSONPath)
+	info := &buildInfo{Repository: "owner/repo", Commit: "abc", RunID: 7, BuiltAt: "2024-01-02T03:04:05Z"}
+
+	tests := []struct {
+		name        string
+		modJSONPath string
+		files       []string
+		target      string
+		// stamped is the file that should carry the build info.
+		stamped string
+	}{
+		{"mod.json at the root", "mod.json", []string{"mod.json", `{"id": "a"}`}, stampModJSON, "mod.json"},
+		{"nested mod.json", "mod.json", []string{"my.mod/mod.json", `{"id": "a"}`, "my.mod/logo.png", "png"}, stampModJSON, "my.mod/mod.json"},
+		{"-mod-json-path", "pkg/mod.json", []string{"mod.json", `{"id": "decoy"}`, "pkg/mod.json", `{"id": "a"}`}, stampModJSON, "pkg/mod.json"},
+		{"build-info.json", "mod.json", []string{"my.mod/mod.json", `{"id": "a"}`}, stampBuildInfo, "my.mod/build-info.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modJSONPath = tt.modJSONPath
+			pkg := &geodePackage{filename: "my.mod.geode", data: testZip(t, tt.files...)}
+			if err := stampPackage(pkg, info, tt.target); err != nil {
+				t.Fatal(err)
+			}
+			files := zipContents(t, pkg.data)
+
+			stamp := new(buildInfo)
+			if tt.target == stampModJSON {
+				var mod struct {
+					BuildInfo *buildInfo `json:"build-info"`
+				}
+				if err := json.Unmarshal([]byte(files[tt.stamped]), &mod); err != nil {
+					t.Fatalf("%s: %v", tt.stamped, err)
+				}
+				stamp = mod.BuildInfo
+			} else if err := json.Unmarshal([]byte(files[tt.stamped]), stamp); err != nil {
+				t.Fatalf("%s: %v", tt.stamped, err)
+			}
+			if stamp == nil || *stamp != *info {
+				t.Errorf("%s carries %+v, want %+v", tt.stamped, stamp, info)
+			}
+			for i := 0; i+1 < len(tt.files); i += 2 {
+				if name := tt.files[i]; name != tt.stamped && files[name] != tt.files[i+1] {
+					t.Errorf("%s was changed", name)
+				}
+			}
+		})
+	}
+
+	pkg := &geodePackage{filename: "my.mod.geode", data: testZip(t, "logo.png", "png")}
+	modJSONPath = "mod.json"
+	if err := stampPackage(pkg, info, stampModJSON); err == nil {
+		t.Error("stampPackage() succeeded without a mod.json")
+	}
+}
+
+func TestStampPackageReproducible(t *testing.T) {
+	committedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := testZip(t, "mod.json", `{"id": "my.mod"}`, "my.mod.dll", "win")
+
+	for _, target := range []string{stampModJSON, stampBuildInfo} {
+		t.Run(target, func(t *testing.T) {
+			var stamped [][]byte
+			for i := range 2 {
+				if i > 0 {
+					// Past the second the timestamp is formatted to.
+					time.Sleep(time.Second)
+				}
+				info := newBuildInfo("owner", "repo", "abc", committedAt, nil)
+				pkg := &geodePackage{filename: "my.mod.geode", data: bytes.Clone(source)}
+				if err := stampPackage(pkg, info, target); err != nil {
+					t.Fatal(err)
+				}
+				stamped = append(stamped, pkg.data)
+			}
+			if !bytes.Equal(stamped[0], stamped[1]) {
+				t.Error("stamping the same build twice gave different packages")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultStateFile = ".gwtreleaser-state.json"
+	maxStateRuns     = 100
+)
+
+// releaseState records which workflow runs have been released, so repeated
+// invocations and the watcher can tell when there is nothing new.
+type releaseState struct {
+	Runs []releasedRun `json:"runs"`
+}
+
+type releasedRun struct {
+	RunID      int64     `json:"run_id"`
+	Tags       []string  `json:"tags,omitempty"`
+	Versions   []string  `json:"versions,omitempty"`
+	ReleasedAt time.Time `json:"released_at"`
+	// Packages are the .geode assets uploaded, so verify can check releases
+	// whose packages were rewritten after the build.
+	Packages []releasedPackage `json:"packages,omitempty"`
+}
+
+// releasedPackage is a .geode asset of a release with the digest of the
+// package it was made from.
+type releasedPackage struct {
+	Tag         string `json:"tag"`
+	Name        string `json:"name"`
+	SHA256      string `json:"sha256"`
+	BuiltSHA256 string `json:"built_sha256"`
+}
+
+func loadReleaseState(path string) (*releaseState, error) {
+	st := new(releaseState)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+	return st, nil
+}
+
+// save writes the state through a temporary file so an interrupted write
+// cannot corrupt it.
+func (st *releaseState) save(path string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (st *releaseState) released(runID int64) bool {
+	return slices.ContainsFunc(st.Runs, func(r releasedRun) bool { return r.RunID == runID })
+}
+
+// latest returns the highest released run ID.
+func (st *releaseState) latest() int64 {
+	var id int64
+	for _, r := range st.Runs {
+		id = max(id, r.RunID)
+	}
+	return id
+}
+
+// record adds runID with the releases made from it, res may be nil for a
+// run that turned out to be released already. Only the most recent runs
+// are kept.
+func (st *releaseState) record(runID int64, res *runResult) {
+	entry := releasedRun{RunID: runID, ReleasedAt: time.Now().UTC()}
+	if res != nil {
+		for _, rel := range res.Releases {
+			entry.Tags = append(entry.Tags, rel.Tag)
+			entry.Versions = append(entry.Versions, rel.Version)
+			for _, a := range rel.Assets {
+				if strings.HasSuffix(a.Name, ".geode") {
+					entry.Packages = append(entry.Packages, releasedPackage{Tag: rel.Tag, Name: a.Name, SHA256: a.SHA256, BuiltSHA256: rel.BuiltSHA256})
+				}
+			}
+		}
+	}
+	st.Runs = append(st.Runs, entry)
+	if len(st.Runs) > maxStateRuns {
+		st.Runs = st.Runs[len(st.Runs)-maxStateRuns:]
+	}
+}
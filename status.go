@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// buildStatus compares the latest successful build with the releases.
+type buildStatus struct {
+	RunID         int64           `json:"run_id"`
+	RunNumber     int             `json:"run_number"`
+	HeadSHA       string          `json:"head_sha"`
+	LatestRelease string          `json:"latest_release,omitempty"`
+	Packages      []packageStatus `json:"packages"`
+	Pending       bool            `json:"pending"`
+}
+
+type packageStatus struct {
+	ModID    string `json:"mod_id"`
+	Version  string `json:"version"`
+	Tag      string `json:"tag"`
+	Released bool   `json:"released"`
+}
+
+// statusMain implements "status": it reports whether the latest successful
+// build still has to be released.
+func statusMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	opts.register(fs)
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	// Only the version matters here, not whether it could be released.
+	opts.requireNewer = false
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	var st *buildStatus
+	if err == nil {
+		st, err = r.status(ctx)
+	}
+	if err == nil {
+		err = writeStatus(os.Stdout, opts.outputFormat, st)
+	}
+	if err != nil {
+		code := exitCodeOf(err)
+		slog.Error("Status failed", "error", err, "exit_code", code)
+		return code
+	}
+	return 0
+}
+
+func (r *releaser) status(ctx context.Context) (*buildStatus, error) {
+	run, err := findLatestRun(ctx, r.client, r.artifactOwner, r.artifactRepo, r.opts.workflowFiles, r.opts.runFilter("success"))
+	if err != nil {
+		return nil, err
+	}
+	zipData, err := r.downloadRunArtifact(ctx, run)
+	if err != nil {
+		return nil, err
+	}
+	pkgs, err := extractGeodePackages(zipData, r.opts.packageLayout, r.opts.mergePackages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract .geode file: %w", err)
+	}
+	r.source = run
+
+	st := &buildStatus{RunID: run.GetID(), RunNumber: run.GetRunNumber(), HeadSHA: run.GetHeadSHA()}
+	latest, _, err := r.client.Repositories.GetLatestRelease(ctx, r.owner, r.repo)
+	switch {
+	case err == nil:
+		st.LatestRelease = latest.GetTagName()
+	case !isStatus(err, http.StatusNotFound):
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+
+	for _, pkg := range pkgs {
+		t, err := r.targetOf(ctx, pkg, run.GetHeadSHA(), len(pkgs) > 1)
+		if err != nil {
+			return nil, err
+		}
+		release, err := getReleaseByTag(ctx, r.client, r.owner, r.repo, t.tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up release %s: %w", t.tag, err)
+		}
+		st.Packages = append(st.Packages, packageStatus{ModID: pkg.mod.ID, Version: t.version, Tag: t.tag, Released: release != nil})
+		st.Pending = st.Pending || release == nil
+	}
+	return st, nil
+}
+
+func writeStatus(w io.Writer, format string, st *buildStatus) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+
+	latest := st.LatestRelease
+	if latest == "" {
+		latest = "none"
+	}
+	fmt.Fprintf(w, "Latest build:   run #%d (%d) at %.7s\n", st.RunNumber, st.RunID, st.HeadSHA)
+	fmt.Fprintf(w, "Latest release: %s\n", latest)
+	for _, p := range st.Packages {
+		state := "not released"
+		if p.Released {
+			state = "released"
+		}
+		fmt.Fprintf(w, "  %s %s: %s %s\n", p.ModID, p.Version, p.Tag, state)
+	}
+	if st.Pending {
+		_, err := fmt.Fprintln(w, "A release is pending.")
+		return err
+	}
+	_, err := fmt.Fprintln(w, "Up to date.")
+	return err
+}
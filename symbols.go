@@ -0,0 +1,157 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+)
+
+// isSymbolFile reports whether the zip entry name is a debug symbols file:
+// a PDB or part of a dSYM bundle.
+func isSymbolFile(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".pdb") || strings.Contains(strings.ToLower(name), ".dsym/")
+}
+
+// hasDebugInfo reports whether data is an ELF object that still carries
+// DWARF debug info.
+func hasDebugInfo(data []byte) bool {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return f.Section(".debug_info") != nil
+}
+
+// artifactSymbols collects the debug symbols shipped next to the packages
+// in the artifact: PDBs, dSYM bundles and unstripped shared libraries.
+func artifactSymbols(zipData []byte) ([]extraAsset, error) {
+	if zipData == nil {
+		return nil, nil
+	}
+	r, err := zip.NewReader(bytes.NewReader(zipData), int64(len(zipData)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip reader: %w", err)
+	}
+
+	var symbols []extraAsset
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		isLib := strings.HasSuffix(f.Name, ".so")
+		if !isSymbolFile(f.Name) && !isLib {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s inside zip: %w", f.Name, err)
+		}
+		if !isSymbolFile(f.Name) && !hasDebugInfo(data) {
+			continue
+		}
+		slog.Debug("Found debug symbols in artifact", "path", f.Name, "bytes", len(data))
+		symbols = append(symbols, extraAsset{name: f.Name, data: data})
+	}
+	return symbols, nil
+}
+
+// stripSymbols removes the PDBs and dSYM bundles from pkg, returning them.
+func stripSymbols(pkg *geodePackage) ([]extraAsset, error) {
+	r, err := zip.NewReader(bytes.NewReader(pkg.data), int64(len(pkg.data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .geode as zip: %w", err)
+	}
+
+	var symbols []extraAsset
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range r.File {
+		if !isSymbolFile(f.Name) {
+			if err := w.Copy(f); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", f.Name, err)
+			}
+			continue
+		}
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s inside .geode: %w", f.Name, err)
+		}
+		symbols = append(symbols, extraAsset{name: f.Name, data: data})
+	}
+	if len(symbols) == 0 {
+		return nil, nil
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Moved debug symbols out of package", "file", pkg.filename, "files", len(symbols), "bytes_before", len(pkg.data), "bytes_after", buf.Len())
+	pkg.data = buf.Bytes()
+	return symbols, nil
+}
+
+// symbolsOf returns the artifact-level symbols in shared that belong to
+// pkg: those in its directory of the artifact and those named after its mod
+// ID. With a single package, all of them do.
+func symbolsOf(pkg *geodePackage, shared []extraAsset, multi bool) []extraAsset {
+	if !multi {
+		return shared
+	}
+	dir := path.Dir(pkg.filename)
+	id := strings.ToLower(pkg.mod.ID)
+	var own []extraAsset
+	for _, s := range shared {
+		name := strings.ToLower(s.name)
+		inDir := dir != "." && strings.HasPrefix(s.name, dir+"/")
+		named := strings.HasPrefix(path.Base(name), id+".") || slices.Contains(strings.Split(path.Dir(name), "/"), id)
+		if inDir || named {
+			own = append(own, s)
+		}
+	}
+	return own
+}
+
+// symbolsBundle zips the symbol files as the symbols-<version>.zip asset.
+// A file whose name is already taken is left out if it is the same file and
+// numbered otherwise.
+func symbolsBundle(version string, files []extraAsset) (extraAsset, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	seen := make(map[string][]byte)
+	for _, f := range files {
+		name := f.name
+		if data, ok := seen[name]; ok {
+			if bytes.Equal(data, f.data) {
+				continue
+			}
+			ext := path.Ext(name)
+			for i := 2; ; i++ {
+				name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(f.name, ext), i, ext)
+				if _, ok := seen[name]; !ok {
+					break
+				}
+			}
+		}
+		seen[name] = f.data
+		fw, err := w.Create(name)
+		if err != nil {
+			return extraAsset{}, err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return extraAsset{}, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return extraAsset{}, fmt.Errorf("failed to zip debug symbols: %w", err)
+	}
+	return extraAsset{name: "symbols-" + version + ".zip", data: buf.Bytes()}, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSymbolsOf(t *testing.T) {
+	shared := []extraAsset{
+		{name: "my.mod.pdb"},
+		{name: "other.mod.pdb"},
+		{name: "build/my.mod.android64.so"},
+		{name: "my.mod.dylib.dSYM/Contents/Resources/DWARF/my.mod.dylib"},
+		{name: "other/libgeode.so"},
+		{name: "symbols/my.mod/libfmt.so"},
+		{name: "shared.pdb"},
+	}
+	names := func(files []extraAsset) []string {
+		var names []string
+		for _, f := range files {
+			names = append(names, f.name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		id       string
+		multi    bool
+		want     []string
+	}{
+		{"single package", "my.mod.geode", "my.mod", false, names(shared)},
+		{"by mod ID", "my.mod.geode", "my.mod", true, []string{"my.mod.pdb", "build/my.mod.android64.so", "my.mod.dylib.dSYM/Contents/Resources/DWARF/my.mod.dylib", "symbols/my.mod/libfmt.so"}},
+		{"by directory", "other/other.mod.geode", "other.mod", true, []string{"other.mod.pdb", "other/libgeode.so"}},
+		{"none", "third.mod.geode", "third.mod", true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := &geodePackage{filename: tt.filename, mod: &ModJSON{ID: tt.id}}
+			if got := names(symbolsOf(pkg, shared, tt.multi)); !slices.Equal(got, tt.want) {
+				t.Errorf("symbolsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymbolsBundleNames(t *testing.T) {
+	bundle, err := symbolsBundle("1.0.0", []extraAsset{
+		{name: "my.mod.pdb", data: []byte("package")},
+		{name: "my.mod.pdb", data: []byte("package")},
+		{name: "my.mod.pdb", data: []byte("artifact")},
+		{name: "my.mod-2.pdb", data: []byte("other")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.name != "symbols-1.0.0.zip" {
+		t.Errorf("bundle name = %q", bundle.name)
+	}
+	got := zipContents(t, bundle.data)
+	want := map[string]string{"my.mod.pdb": "package", "my.mod-2.pdb": "artifact", "my.mod-2-2.pdb": "other"}
+	if len(got) != len(want) {
+		t.Fatalf("bundle has %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s = %q, want %q", name, got[name], content)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarballCompression returns "gzip" or "zstd" for file names of compressed
+// tarballs, or "" for anything else.
+func tarballCompression(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "gzip"
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		return "zstd"
+	}
+	return ""
+}
+
+// readTarball calls fn with the path and contents of every regular file in
+// the compressed tarball data. The compression is detected from the stream's
+// magic bytes, falling back to the file name.
+func readTarball(name string, data []byte, fn func(path string, data []byte) error) error {
+	compression := tarballCompression(name)
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		compression = "gzip"
+	case bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		compression = "zstd"
+	}
+
+	var r io.Reader
+	switch compression {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		defer gz.Close()
+		r = gz
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return fmt.Errorf("%s: unknown tarball compression", name)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tarball %s: %w", name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		contents, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from tarball %s: %w", hdr.Name, name, err)
+		}
+		if err := fn(hdr.Name, contents); err != nil {
+			return err
+		}
+	}
+}
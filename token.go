@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// storedTokenPath returns where "login" stores the token when there is no
+// keychain, in the user config directory.
+func storedTokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the user config directory: %w", err)
+	}
+	return filepath.Join(dir, "gwtreleaser", "token"), nil
+}
+
+// Keychain entry holding the token stored by "login".
+const (
+	keyringService = "gwtreleaser"
+	keyringUser    = "github.com"
+)
+
+// saveStoredToken stores token in the OS keychain, or where only the current
+// user can read it if there is no keychain, and describes where it went.
+func saveStoredToken(token string) (string, error) {
+	err := keyring.Set(keyringService, keyringUser, token)
+	if err == nil {
+		return "the system keychain", nil
+	}
+	slog.Debug("Keychain unavailable, storing the token in a file", "error", err)
+
+	path, err := storedTokenPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create token directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return path, nil
+}
+
+// githubToken returns $GITHUB_TOKEN, falling back to the token stored by
+// "login" in the OS keychain or token file and then to whatever git's
+// credential helper has for github.com.
+func githubToken() (string, error) {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token, nil
+	}
+	if token, err := keyring.Get(keyringService, keyringUser); err == nil && token != "" {
+		slog.Debug("Using token from the system keychain")
+		return token, nil
+	}
+	if path, err := storedTokenPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			if token := strings.TrimSpace(string(data)); token != "" {
+				slog.Debug("Using stored token", "path", path)
+				return token, nil
+			}
+		}
+	}
+	if token, err := gitCredentialToken(); err == nil && token != "" {
+		slog.Debug("Using token from the git credential helper")
+		return token, nil
+	}
+	return "", withExitCode(exitAuth, errors.New("GITHUB_TOKEN environment variable must be set, or a token stored with \"gwtreleaser login\" or a git credential helper"))
+}
+
+// gitCredentialToken asks git's configured credential helpers for the
+// github.com password, which for GitHub is a token. Git is not allowed to
+// prompt for one.
+func gitCredentialToken() (string, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=github.com\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if v, ok := strings.CutPrefix(line, "password="); ok {
+			return v, nil
+		}
+	}
+	return "", nil
+}
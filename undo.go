@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// undoMain implements "undo": it deletes a release, its assets and its tag
+// after asking for confirmation.
+func undoMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet("undo", flag.ExitOnError)
+	opts.register(fs)
+	tag := fs.String("tag", "", "Tag of the release to delete (required)")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *tag == "" {
+		fmt.Fprintln(os.Stderr, "-tag is required")
+		return exitUsage
+	}
+	if !*yes && !isTerminal(os.Stdin) {
+		fmt.Fprintln(os.Stderr, "undo needs a terminal to confirm on, or -yes")
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	if err == nil {
+		err = r.undo(ctx, *tag, *yes)
+	}
+	if err != nil {
+		code := exitCodeOf(err)
+		slog.Error("Undo failed", "error", err, "exit_code", code)
+		return code
+	}
+	return 0
+}
+
+// undo deletes the release of tag together with its assets, then the tag.
+// Either may already be gone.
+func (r *releaser) undo(ctx context.Context, tag string, yes bool) error {
+	release, err := getReleaseByTag(ctx, r.client, r.owner, r.repo, tag)
+	if err != nil {
+		return fmt.Errorf("failed to look up release: %w", err)
+	}
+	_, _, err = r.client.Git.GetRef(ctx, r.owner, r.repo, "refs/tags/"+tag)
+	hasTag := err == nil
+	if err != nil && !isStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("failed to look up tag: %w", err)
+	}
+	if release == nil && !hasTag {
+		return fmt.Errorf("there is no release or tag %s in %s/%s", tag, r.owner, r.repo)
+	}
+
+	if !yes {
+		what := "tag " + tag
+		if release != nil {
+			what = fmt.Sprintf("release %q with %d assets and tag %s", release.GetName(), len(release.Assets), tag)
+		}
+		ok, err := newPrompter().confirm(fmt.Sprintf("Delete %s from %s/%s?", what, r.owner, r.repo))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errCancelled
+		}
+	}
+
+	if release != nil {
+		// Deleting the release deletes its assets with it.
+		if _, err := r.client.Repositories.DeleteRelease(ctx, r.owner, r.repo, release.GetID()); err != nil {
+			return fmt.Errorf("failed to delete release: %w", err)
+		}
+		slog.Info("Deleted release", "tag", tag, "release_id", release.GetID(), "assets", len(release.Assets))
+	}
+	if hasTag {
+		if err := deleteTag(ctx, r.client, r.owner, r.repo, tag); err != nil {
+			return fmt.Errorf("failed to delete tag: %w", err)
+		}
+		slog.Info("Deleted tag", "tag", tag)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// updateManifest is the latest.json a mod can fetch to check for updates.
+type updateManifest struct {
+	ID          string `json:"id"`
+	Version     string `json:"version"`
+	DownloadURL string `json:"download_url"`
+	SHA256      string `json:"sha256"`
+	Geode       string `json:"geode,omitempty"`
+}
+
+// updateManifestName is the asset name of the update manifest. Artifacts
+// carrying several mods get one manifest per mod.
+func updateManifestName(pkg *geodePackage, multi bool) string {
+	if multi {
+		return pkg.mod.ID + "-latest.json"
+	}
+	return "latest.json"
+}
+
+// newUpdateManifest describes the package uploaded as asset at version.
+func newUpdateManifest(pkg *geodePackage, version string, asset *assetResult) ([]byte, error) {
+	data, err := json.MarshalIndent(updateManifest{
+		ID:          pkg.mod.ID,
+		Version:     version,
+		DownloadURL: asset.DownloadURL,
+		SHA256:      asset.SHA256,
+		Geode:       pkg.mod.Geode,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode update manifest: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// publishUpdateManifest replaces the update manifest on the -updates-release
+// release, creating that release at the released commit if it does not
+// exist yet. It is never marked latest so it does not hide real releases.
+func (r *releaser) publishUpdateManifest(ctx context.Context, pr *pendingRelease) error {
+	client, owner, repo, tag := r.client, r.owner, r.repo, r.opts.updatesRelease
+	release, err := getReleaseByTag(ctx, client, owner, repo, tag)
+	if err != nil {
+		return fmt.Errorf("failed to look up updates release: %w", err)
+	}
+	if release == nil {
+		release, _, err = client.Repositories.CreateRelease(ctx, owner, repo, &github.RepositoryRelease{
+			TagName:         github.String(tag),
+			TargetCommitish: github.String(pr.commitSHA),
+			Name:            github.String("Updates"),
+			Body:            github.String("Update manifests pointing at the latest release of each mod."),
+			MakeLatest:      github.String("false"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create updates release: %w", err)
+		}
+		slog.Info("Created updates release", "tag", tag, "release_id", release.GetID())
+	}
+
+	if _, err := r.uploadAsset(ctx, release, pr.manifest.name, pr.manifest.data, true); err != nil {
+		return err
+	}
+	slog.Info("Published update manifest", "tag", tag, "name", pr.manifest.name, "version", pr.version)
+	return nil
+}
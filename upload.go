@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// builtinContentTypes covers extensions the system MIME tables do not know.
+// .geode packages are zip archives.
+var builtinContentTypes = map[string]string{
+	".geode": "application/zip",
+}
+
+// assetContentType picks the Content-Type for an asset called name. Entries
+// of overrides, keyed by lower-case extension, take precedence over the
+// built-in and system types.
+func assetContentType(name string, overrides map[string]string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if t, ok := overrides[ext]; ok {
+		return t
+	}
+	if t, ok := builtinContentTypes[ext]; ok {
+		return t
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// parseContentTypes parses -content-type values of the form ".ext=type".
+func parseContentTypes(values []string) (map[string]string, error) {
+	types := make(map[string]string, len(values))
+	for _, v := range values {
+		ext, typ, ok := strings.Cut(v, "=")
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		typ = strings.TrimSpace(typ)
+		if !ok || ext == "" || typ == "" {
+			return nil, fmt.Errorf("invalid -content-type %q (want .ext=type)", v)
+		}
+		if _, _, err := mime.ParseMediaType(typ); err != nil {
+			return nil, fmt.Errorf("invalid -content-type %q: %w", v, err)
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		types[ext] = typ
+	}
+	return types, nil
+}
+
+// uploadReleaseAsset uploads size bytes from r as an asset named name on the
+// given release. It mirrors RepositoriesService.UploadReleaseAsset but accepts
+// any reader so the transfer can be metered.
+func uploadReleaseAsset(ctx context.Context, client *github.Client, owner, repo string, releaseID int64, name, contentType string, r io.Reader, size int64) (*github.ReleaseAsset, error) {
+	u := fmt.Sprintf("repos/%s/%s/releases/%d/assets?name=%s", owner, repo, releaseID, url.QueryEscape(name))
+
+	p := newProgress("Uploading "+name, size)
+	req, err := client.NewUploadRequest(u, progressReader(r, p), size, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	asset := new(github.ReleaseAsset)
+	if _, err := client.Do(ctx, req, asset); err != nil {
+		return nil, err
+	}
+	p.finish()
+	return asset, nil
+}
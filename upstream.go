@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// useUpstream points opts at the parent of the -owner/-repo fork for the
+// release, keeping the fork as the source of workflow runs and artifacts.
+func useUpstream(ctx context.Context, client *github.Client, opts *options) error {
+	fork, _, err := client.Repositories.Get(ctx, opts.owner, opts.repo)
+	if err != nil {
+		return fmt.Errorf("failed to get repository %s/%s: %w", opts.owner, opts.repo, err)
+	}
+	parent := fork.GetParent()
+	if !fork.GetFork() || parent == nil {
+		return fmt.Errorf("%s is not a fork", fork.GetFullName())
+	}
+	// The fork's permissions say nothing about the parent, so ask again.
+	upstream, _, err := client.Repositories.Get(ctx, parent.GetOwner().GetLogin(), parent.GetName())
+	if err != nil {
+		return fmt.Errorf("failed to get upstream repository %s: %w", parent.GetFullName(), err)
+	}
+	if !upstream.GetPermissions()["push"] {
+		return withExitCode(exitAuth, fmt.Errorf("the token cannot push to upstream repository %s", upstream.GetFullName()))
+	}
+
+	slog.Info("Releasing fork build to upstream", "fork", fork.GetFullName(), "upstream", upstream.GetFullName())
+	opts.artifactOwner, opts.artifactRepo = opts.owner, opts.repo
+	opts.owner, opts.repo = upstream.GetOwner().GetLogin(), upstream.GetName()
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// artifactMetadata holds the fields of an artifact used to check a download.
+// go-github does not model the digest yet, so it is decoded here directly.
+type artifactMetadata struct {
+	SizeInBytes int64  `json:"size_in_bytes"`
+	Digest      string `json:"digest"`
+}
+
+func getArtifactMetadata(ctx context.Context, client *github.Client, owner, repo string, artifactID int64) (*artifactMetadata, error) {
+	req, err := client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/actions/artifacts/%d", owner, repo, artifactID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := new(artifactMetadata)
+	if _, err := client.Do(ctx, req, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// verifyArtifact checks downloaded artifact data against the size and, when
+// the API provides one, the digest reported for the artifact.
+func verifyArtifact(data []byte, meta *artifactMetadata) error {
+	if meta.SizeInBytes > 0 && int64(len(data)) != meta.SizeInBytes {
+		return fmt.Errorf("downloaded %d bytes but the artifact is %d bytes", len(data), meta.SizeInBytes)
+	}
+
+	algo, want, ok := strings.Cut(meta.Digest, ":")
+	if !ok {
+		slog.Debug("Artifact has no digest, verified size only", "bytes", len(data))
+		return nil
+	}
+	if algo != "sha256" {
+		slog.Warn("Cannot verify artifact digest with unsupported algorithm", "digest", meta.Digest)
+		return nil
+	}
+	if got := sha256Hex(data); !strings.EqualFold(got, want) {
+		return fmt.Errorf("artifact digest mismatch: got sha256:%s, want %s", got, meta.Digest)
+	}
+
+	slog.Debug("Verified artifact download", "bytes", len(data), "digest", meta.Digest)
+	return nil
+}
+
+// verifyUploadedAsset downloads asset back from the release and checks that
+// GitHub stored exactly data.
+func (r *releaser) verifyUploadedAsset(ctx context.Context, asset *github.ReleaseAsset, data []byte) error {
+	if size := int64(asset.GetSize()); size != int64(len(data)) {
+		return fmt.Errorf("uploaded %d bytes but the stored asset is %d bytes", len(data), size)
+	}
+
+	rc, _, err := r.client.Repositories.DownloadReleaseAsset(ctx, r.owner, r.repo, asset.GetID(), r.http)
+	if err != nil {
+		return fmt.Errorf("failed to download asset for verification: %w", err)
+	}
+	defer rc.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, rc); err != nil {
+		return fmt.Errorf("failed to download asset for verification: %w", err)
+	}
+
+	want := sha256Hex(data)
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("stored asset digest mismatch: got sha256:%s, want sha256:%s", got, want)
+	}
+	slog.Debug("Verified uploaded asset", "name", asset.GetName(), "sha256", want)
+	return nil
+}
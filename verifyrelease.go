@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+	"golang.org/x/mod/semver"
+)
+
+// verifyMain implements "verify": it checks that the .geode assets of a past
+// release still match the build artifact they were released from.
+func verifyMain(args []string) int {
+	var opts options
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	opts.register(fs)
+	tag := fs.String("tag", "", "Tag of the release to verify (required)")
+	if err := opts.parse(fs, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *tag == "" {
+		fmt.Fprintln(os.Stderr, "-tag is required")
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	if err == nil {
+		err = r.verifyRelease(ctx, *tag)
+	}
+	if err != nil {
+		code := exitCodeOf(err)
+		slog.Error("Verification failed", "error", err, "exit_code", code)
+		return code
+	}
+	fmt.Printf("Release %s matches its build\n", *tag)
+	return 0
+}
+
+// verifyRelease compares every .geode asset of the release of tag with the
+// package of the same mod in the originating artifact: their digests must
+// be equal and the version must be the one in the tag. Packages rewritten
+// when they were released, by -symbols, -stamp or -split-platforms, are
+// checked against the digests recorded in the state file instead.
+func (r *releaser) verifyRelease(ctx context.Context, tag string) error {
+	release, err := getReleaseByTag(ctx, r.client, r.owner, r.repo, tag)
+	if err != nil {
+		return fmt.Errorf("failed to look up release: %w", err)
+	}
+	if release == nil {
+		return fmt.Errorf("there is no release %s in %s/%s", tag, r.owner, r.repo)
+	}
+
+	var recorded *releasedRun
+	if r.opts.stateFile != "" {
+		st, err := loadReleaseState(r.opts.stateFile)
+		if err != nil {
+			return err
+		}
+		if i := slices.IndexFunc(st.Runs, func(run releasedRun) bool { return slices.Contains(run.Tags, tag) }); i >= 0 {
+			recorded = &st.Runs[i]
+		}
+	}
+
+	run, err := r.releaseSourceRun(ctx, tag, recorded)
+	if err != nil {
+		return err
+	}
+	slog.Info("Verifying against workflow run", "run_id", run.GetID(), "head_sha", run.GetHeadSHA())
+	zipData, err := r.downloadRunArtifact(ctx, run)
+	if err != nil {
+		return err
+	}
+	built, err := extractGeodePackages(zipData, r.opts.packageLayout, r.opts.mergePackages)
+	if err != nil {
+		return fmt.Errorf("failed to extract .geode file: %w", err)
+	}
+
+	var problems []string
+	checked := 0
+	for _, a := range release.Assets {
+		if !strings.HasSuffix(a.GetName(), ".geode") {
+			continue
+		}
+		data, err := r.downloadReleaseAsset(ctx, a)
+		if err != nil {
+			return err
+		}
+		pkg, err := newGeodePackage(a.GetName(), data)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", a.GetName(), err))
+			continue
+		}
+		checked++
+
+		i := slices.IndexFunc(built, func(b *geodePackage) bool { return b.mod.ID == pkg.mod.ID })
+		if i < 0 {
+			problems = append(problems, fmt.Sprintf("%s: mod %s is not in the artifact of run %d", a.GetName(), pkg.mod.ID, run.GetID()))
+			continue
+		}
+		if problem := digestProblem(a.GetName(), tag, sha256Hex(data), built[i].built, recorded); problem != "" {
+			problems = append(problems, problem)
+			continue
+		}
+		if !tagMatchesVersion(tag, r.opts.tagPrefix, pkg.mod.ID, pkg.mod.Version) {
+			problems = append(problems, fmt.Sprintf("%s: mod.json version %s does not match the tag", a.GetName(), pkg.mod.Version))
+			continue
+		}
+		slog.Info("Asset matches its build", "name", a.GetName(), "mod_id", pkg.mod.ID, "version", pkg.mod.Version)
+	}
+
+	if checked == 0 && len(problems) == 0 {
+		return fmt.Errorf("release %s has no .geode assets", tag)
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// digestProblem checks the sha256 of the released asset name against the
+// package built as built. With a state file entry recording the asset, both
+// must be the recorded digests; otherwise the asset must be the package as
+// built. It returns "" if they match.
+func digestProblem(name, tag, released, built string, recorded *releasedRun) string {
+	if recorded != nil {
+		i := slices.IndexFunc(recorded.Packages, func(p releasedPackage) bool { return p.Tag == tag && p.Name == name })
+		if i >= 0 {
+			p := recorded.Packages[i]
+			switch {
+			case released != p.SHA256:
+				return fmt.Sprintf("%s: sha256 %s differs from the recorded %s", name, released, p.SHA256)
+			case built != p.BuiltSHA256:
+				return fmt.Sprintf("%s: built sha256 %s differs from the recorded %s", name, built, p.BuiltSHA256)
+			}
+			return ""
+		}
+	}
+	if released != built {
+		return fmt.Sprintf("%s: sha256 %s differs from the built %s (packages rewritten by -symbols, -stamp or -split-platforms need -state)", name, released, built)
+	}
+	return ""
+}
+
+// tagMatchesVersion reports whether tag, which is tagPrefix or a mod ID
+// namespaced tagPrefix followed by a version, is the release of version,
+// as is or suffixed for a channel.
+func tagMatchesVersion(tag, tagPrefix, modID, version string) bool {
+	if strings.HasPrefix(tag, modID+"/") {
+		tagPrefix = modID + "/" + tagPrefix
+	}
+	tagVersion, err := versionFromTag(tag, tagPrefix)
+	if err != nil {
+		return false
+	}
+	version, err = normalizeVersion(version)
+	if err != nil {
+		return false
+	}
+	if tagVersion == version {
+		return true
+	}
+	// Channel releases suffix a release version with a prerelease.
+	pre := semver.Prerelease("v" + tagVersion)
+	return pre != "" && semver.Prerelease("v"+version) == "" && strings.TrimSuffix(tagVersion, pre) == version
+}
+
+// releaseSourceRun finds the workflow run tag was released from: -run-id if
+// given, else the run recorded in the state file, else the latest successful
+// run of the tagged commit.
+func (r *releaser) releaseSourceRun(ctx context.Context, tag string, recorded *releasedRun) (*github.WorkflowRun, error) {
+	if r.opts.runID != 0 {
+		return getRun(ctx, r.client, r.artifactOwner, r.artifactRepo, r.opts.runID)
+	}
+	if recorded != nil {
+		return getRun(ctx, r.client, r.artifactOwner, r.artifactRepo, recorded.RunID)
+	}
+
+	sha, err := r.tagCommit(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	filter := r.opts.runFilter("success")
+	filter.Branch, filter.HeadSHA = "", sha
+	_, runs, err := listRuns(ctx, r.client, r.artifactOwner, r.artifactRepo, r.opts.workflowFiles, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, withExitCode(exitNoRuns, fmt.Errorf("no successful run of %s built %.7s, the commit of %s; pass -run-id", r.opts.workflowFile, sha, tag))
+	}
+	return runs[0], nil
+}
+
+// tagCommit returns the commit tag points to, peeling annotated tags.
+func (r *releaser) tagCommit(ctx context.Context, tag string) (string, error) {
+	ref, _, err := r.client.Git.GetRef(ctx, r.owner, r.repo, "refs/tags/"+tag)
+	if err != nil {
+		return "", fmt.Errorf("failed to get tag %s: %w", tag, err)
+	}
+	if ref.GetObject().GetType() != "tag" {
+		return ref.GetObject().GetSHA(), nil
+	}
+	obj, _, err := r.client.Git.GetTag(ctx, r.owner, r.repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return "", fmt.Errorf("failed to get tag %s: %w", tag, err)
+	}
+	return obj.GetObject().GetSHA(), nil
+}
+
+func (r *releaser) downloadReleaseAsset(ctx context.Context, a *github.ReleaseAsset) ([]byte, error) {
+	rc, _, err := r.client.Repositories.DownloadReleaseAsset(ctx, r.owner, r.repo, a.GetID(), r.http)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", a.GetName(), err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", a.GetName(), err)
+	}
+	return data, nil
+}
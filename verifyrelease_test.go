@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestTagMatchesVersion(t *testing.T) {
+	tests := []struct {
+		tag, tagPrefix, modID, version string
+		want                           bool
+	}{
+		{"v1.2.0", "v", "my.mod", "1.2.0", true},
+		{"v1.2.0", "v", "my.mod", "v1.2.0", true},
+		{"my.mod/v1.2.0", "v", "my.mod", "1.2.0", true},
+		{"v1.2.0-beta.3", "v", "my.mod", "1.2.0", true},
+		{"v1.2.0-beta.3", "v", "my.mod", "1.2.0-beta.3", true},
+		{"v1.2.0-beta.3", "v", "my.mod", "1.2.0-beta.2", false},
+		{"v1.2.0", "v", "my.mod", "1.2.0-beta.3", false},
+		{"v1.2.1", "v", "my.mod", "1.2.0", false},
+		{"v1.2.10", "v", "my.mod", "1.2.1", false},
+		{"other.mod/v1.2.0", "v", "my.mod", "1.2.0", false},
+		{"release-1.2.0", "v", "my.mod", "1.2.0", false},
+	}
+	for _, tt := range tests {
+		if got := tagMatchesVersion(tt.tag, tt.tagPrefix, tt.modID, tt.version); got != tt.want {
+			t.Errorf("tagMatchesVersion(%q, %q, %q, %q) = %v, want %v", tt.tag, tt.tagPrefix, tt.modID, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestDigestProblem(t *testing.T) {
+	recorded := &releasedRun{Packages: []releasedPackage{
+		{Tag: "v1.0.0", Name: "my.mod.geode", SHA256: "stamped", BuiltSHA256: "built"},
+	}}
+	tests := []struct {
+		name            string
+		asset, tag      string
+		released, built string
+		recorded        *releasedRun
+		wantProblem     bool
+	}{
+		{"unchanged package", "my.mod.geode", "v1.0.0", "built", "built", nil, false},
+		{"rewritten package without state", "my.mod.geode", "v1.0.0", "stamped", "built", nil, true},
+		{"rewritten package with state", "my.mod.geode", "v1.0.0", "stamped", "built", recorded, false},
+		{"replaced asset", "my.mod.geode", "v1.0.0", "other", "built", recorded, true},
+		{"different build", "my.mod.geode", "v1.0.0", "stamped", "other", recorded, true},
+		{"not recorded", "my.mod-win.geode", "v1.0.0", "stamped", "built", recorded, true},
+		{"recorded for another tag", "my.mod.geode", "v1.1.0", "built", "built", recorded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := digestProblem(tt.asset, tt.tag, tt.released, tt.built, tt.recorded)
+			if (got != "") != tt.wantProblem {
+				t.Errorf("digestProblem() = %q, want a problem: %v", got, tt.wantProblem)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// normalizeVersion validates v as a full major.minor.patch semantic version
+// and returns its canonical form without a leading "v". Build metadata is
+// dropped since it has no meaning for ordering releases.
+func normalizeVersion(v string) (string, error) {
+	sv := "v" + strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if !semver.IsValid(sv) || semver.Canonical(sv)+semver.Build(sv) != sv {
+		return "", fmt.Errorf("%q is not a valid semantic version (expected major.minor.patch)", v)
+	}
+	return strings.TrimPrefix(semver.Canonical(sv), "v"), nil
+}
+
+// compareVersions compares two normalized versions by semver precedence,
+// returning -1, 0 or +1.
+func compareVersions(a, b string) int {
+	return semver.Compare("v"+a, "v"+b)
+}
+
+// versionFromTag strips prefix from a tag name and normalizes the remainder.
+func versionFromTag(tag, prefix string) (string, error) {
+	return normalizeVersion(strings.TrimPrefix(tag, prefix))
+}
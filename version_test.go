@@ -0,0 +1,78 @@
+package main
+
+import "testing"
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"1.2.3", "1.2.3", false},
+		{"v1.2.3", "1.2.3", false},
+		{" 1.2.3 ", "1.2.3", false},
+		{"1.2.3-beta.1", "1.2.3-beta.1", false},
+		{"1.2.3+build.5", "1.2.3", false},
+		{"1.2", "", true},
+		{"1", "", true},
+		{"01.2.3", "", true},
+		{"1.2.3.4", "", true},
+		{"", "", true},
+		{"latest", "", true},
+	}
+	for _, tt := range tests {
+		got, err := normalizeVersion(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("normalizeVersion(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeVersion(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.0", 1},
+		{"2.0.0", "11.0.0", -1},
+		{"1.0.0-beta.1", "1.0.0", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.10", -1},
+		{"1.0.0-alpha.1", "1.0.0-beta.1", -1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVersionFromTag(t *testing.T) {
+	tests := []struct {
+		tag, prefix string
+		want        string
+		wantErr     bool
+	}{
+		{"v1.2.3", "v", "1.2.3", false},
+		{"1.2.3", "v", "1.2.3", false},
+		{"my.mod/v1.2.3", "my.mod/v", "1.2.3", false},
+		{"other.mod/v1.2.3", "my.mod/v", "", true},
+		{"release-1.2.3", "release-", "1.2.3", false},
+		{"v1.2", "v", "", true},
+	}
+	for _, tt := range tests {
+		got, err := versionFromTag(tt.tag, tt.prefix)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("versionFromTag(%q, %q) error = %v, wantErr %v", tt.tag, tt.prefix, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("versionFromTag(%q, %q) = %q, want %q", tt.tag, tt.prefix, got, tt.want)
+		}
+	}
+}
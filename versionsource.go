@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v55/github"
+)
+
+// versionSource provides the version to release a package as. Version
+// returns "" when the source has no version to offer, so that the next
+// source in the chain is consulted.
+type versionSource interface {
+	Name() string
+	Version(ctx context.Context, pkg *geodePackage) (string, error)
+}
+
+// versionSourceNames are the sources accepted by -version-source.
+var versionSourceNames = []string{"flag", "mod.json", "version-file", "cmake"}
+
+// modJSONVersion takes the version from the package's mod.json.
+type modJSONVersion struct{}
+
+func (modJSONVersion) Name() string { return "mod.json" }
+
+func (modJSONVersion) Version(_ context.Context, pkg *geodePackage) (string, error) {
+	return pkg.mod.Version, nil
+}
+
+// flagVersion is the version given with -version.
+type flagVersion string
+
+func (flagVersion) Name() string { return "flag" }
+
+func (v flagVersion) Version(context.Context, *geodePackage) (string, error) {
+	return string(v), nil
+}
+
+// repoFileVersion reads the version from a file in the repository at the
+// released commit.
+type repoFileVersion struct {
+	r     *releaser
+	ref   string
+	path  string
+	parse func(content string) string
+}
+
+func (s *repoFileVersion) Name() string { return s.path }
+
+func (s *repoFileVersion) Version(ctx context.Context, _ *geodePackage) (string, error) {
+	content, err := repoFile(ctx, s.r.client, s.r.owner, s.r.repo, s.path, s.ref)
+	if err != nil || content == "" {
+		return "", err
+	}
+	return s.parse(content), nil
+}
+
+var cmakeProjectVersion = regexp.MustCompile(`(?is)project\s*\([^)]*?\bVERSION\s+"?([0-9][0-9A-Za-z.\-+]*)`)
+
+// repoFile returns the content of path at ref, or "" if it does not exist.
+func repoFile(ctx context.Context, client *github.Client, owner, repo, path, ref string) (string, error) {
+	file, _, _, err := client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+	if isStatus(err, http.StatusNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+	}
+	if file == nil {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	return file.GetContent()
+}
+
+// versionSources builds the -version-source chain for a release of
+// commitSHA.
+func (r *releaser) versionSources(commitSHA string) []versionSource {
+	var sources []versionSource
+	for _, name := range r.opts.versionSources {
+		switch name {
+		case "flag":
+			sources = append(sources, flagVersion(r.opts.version))
+		case "mod.json":
+			sources = append(sources, modJSONVersion{})
+		case "version-file":
+			sources = append(sources, &repoFileVersion{r: r, ref: commitSHA, path: "VERSION", parse: strings.TrimSpace})
+		case "cmake":
+			sources = append(sources, &repoFileVersion{r: r, ref: commitSHA, path: "CMakeLists.txt", parse: func(content string) string {
+				if m := cmakeProjectVersion.FindStringSubmatch(content); m != nil {
+					return m[1]
+				}
+				return ""
+			}})
+		}
+	}
+	return sources
+}
+
+// resolveVersion returns the normalized version from the first source in
+// the chain that has one.
+func (r *releaser) resolveVersion(ctx context.Context, pkg *geodePackage, commitSHA string) (string, error) {
+	for _, src := range r.versionSources(commitSHA) {
+		raw, err := src.Version(ctx, pkg)
+		if err != nil {
+			return "", fmt.Errorf("version source %s: %w", src.Name(), err)
+		}
+		if raw == "" {
+			slog.Debug("Version source has no version", "source", src.Name())
+			continue
+		}
+		version, err := normalizeVersion(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid version from %s: %w", src.Name(), err)
+		}
+		slog.Info("Parsed version", "mod_id", pkg.mod.ID, "version", version, "raw", raw, "source", src.Name())
+		return version, nil
+	}
+	return "", fmt.Errorf("no version found for %s in %s", pkg.mod.ID, strings.Join(r.opts.versionSources, ", "))
+}
+
+// parseVersionSources parses the comma-separated -version-source chain.
+func parseVersionSources(list string) ([]string, error) {
+	var sources []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(versionSourceNames, name) {
+			return nil, fmt.Errorf("unknown version source %q (want %s)", name, strings.Join(versionSourceNames, ", "))
+		}
+		sources = append(sources, name)
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("-version-source must name at least one source")
+	}
+	return sources, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// watchMain implements "watch": it polls the workflow and releases each
+// completed, successful run newer than the last one recorded in the state
+// file. When several runs finished between two polls only the newest is
+// released.
+func watchMain(args []string) int {
+	var opts options
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	opts.register(flags)
+	interval := flags.Duration("interval", 5*time.Minute, "How often to check for new workflow runs")
+	if err := opts.parse(flags, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if opts.bump != "" || opts.runID != 0 || opts.file != "" || opts.buildCmd != "" || opts.interactive {
+		fmt.Fprintln(os.Stderr, "-bump, -run-id, -file, -build-cmd and -interactive cannot be used with watch")
+		return exitUsage
+	}
+
+	if opts.stateFile == "" {
+		opts.stateFile = defaultStateFile
+	}
+	st, err := loadReleaseState(opts.stateFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	ctx, stop := signalContext(opts.timeout)
+	defer stop()
+
+	r, err := newReleaser(ctx, &opts)
+	if err != nil {
+		slog.Error("Watch failed", "error", err)
+		return exitCodeOf(err)
+	}
+
+	slog.Info("Watching for new workflow runs", "workflow", opts.workflowFile, "branch", opts.branch, "interval", *interval, "last_run_id", st.latest())
+	for {
+		latest, err := findLatestRun(ctx, r.client, r.artifactOwner, r.artifactRepo, opts.workflowFiles, opts.runFilter("completed"))
+		switch {
+		case ctx.Err() != nil:
+			return 0
+		case err != nil && exitCodeOf(err) != exitNoRuns:
+			slog.Warn("Failed to check for new runs", "error", err)
+		case err != nil || latest.GetID() <= st.latest():
+			// Nothing new since the last poll.
+		case latest.GetConclusion() != "success":
+			slog.Debug("Latest run did not succeed", "run_id", latest.GetID(), "conclusion", latest.GetConclusion())
+		default:
+			r.releaseRun(ctx, latest.GetID())
+			if ctx.Err() != nil {
+				return 0
+			}
+			// The run records itself in the state file on success.
+			if st, err = loadReleaseState(opts.stateFile); err != nil {
+				slog.Error("Failed to reload state file", "path", opts.stateFile, "error", err)
+				return exitFailure
+			}
+		}
+
+		if err := sleepContext(ctx, *interval); err != nil {
+			return 0
+		}
+	}
+}
+
+// releaseRun releases runID, logging rather than returning failures. A run
+// whose version is already released is recorded as done in the state file;
+// other failures leave it to be retried.
+func (r *releaser) releaseRun(ctx context.Context, runID int64) {
+	slog.Info("Releasing new workflow run", "run_id", runID)
+	r.opts.runID = runID
+	defer func() { r.opts.runID = 0 }()
+
+	res, err := r.run(ctx)
+	switch {
+	case err == nil:
+		if err := writeResult(os.Stdout, r.opts.outputFormat, res); err != nil {
+			slog.Error("Failed to write result", "error", err)
+		}
+	case exitCodeOf(err) == exitTagExists:
+		slog.Info("Run is already released", "run_id", runID, "error", err)
+		r.recordRun(runID, nil)
+	default:
+		slog.Error("Release failed", "run_id", runID, "error", err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const notifyTimeout = 30 * time.Second
+
+// webhookEvent is the data available to generic webhook payload templates.
+// Without a template it is sent as JSON as-is.
+type webhookEvent struct {
+	Status   string          `json:"status"`
+	Error    string          `json:"error,omitempty"`
+	Repo     string          `json:"repo"`
+	RunID    int64           `json:"run_id,omitempty"`
+	Releases []*announcement `json:"releases,omitempty"`
+}
+
+// webhookPayload renders the payload for ev from the template file, if any.
+// Templates get a json function for safely embedding values.
+func webhookPayload(templateFile string, ev *webhookEvent) ([]byte, error) {
+	if templateFile == "" {
+		return json.Marshal(ev)
+	}
+
+	src, err := os.ReadFile(templateFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook template: %w", err)
+	}
+	t, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+
+	var out strings.Builder
+	if err := t.Execute(&out, ev); err != nil {
+		return nil, fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	if !json.Valid([]byte(out.String())) {
+		return nil, fmt.Errorf("webhook template %s did not render valid JSON", templateFile)
+	}
+	return []byte(out.String()), nil
+}
+
+// sendWebhooks POSTs ev to every configured webhook URL.
+func sendWebhooks(ctx context.Context, client *http.Client, urls []string, templateFile string, ev *webhookEvent) {
+	if len(urls) == 0 {
+		return
+	}
+
+	payload, err := webhookPayload(templateFile, ev)
+	if err != nil {
+		slog.Error("Failed to build webhook payload", "error", err)
+		return
+	}
+	for _, u := range urls {
+		if err := postJSON(ctx, client, u, json.RawMessage(payload), nil); err != nil {
+			slog.Error("Failed to call webhook", "url", redactURL(u), "error", err)
+		} else {
+			slog.Info("Called webhook", "url", redactURL(u), "status", ev.Status)
+		}
+	}
+}
+
+// notifyFailure reports a failed run to the generic webhooks. It runs with
+// its own context since the run's may have been cancelled.
+func (r *releaser) notifyFailure(runID int64, runErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	sendWebhooks(ctx, r.http, r.opts.webhooks, r.opts.webhookTemplate, &webhookEvent{
+		Status: "failure",
+		Error:  runErr.Error(),
+		Repo:   r.owner + "/" + r.repo,
+		RunID:  runID,
+	})
+}
+
+// redactURL strips the userinfo, path and query from u for logging, since
+// webhook URLs usually embed their secret.
+func redactURL(u string) string {
+	scheme, rest, ok := strings.Cut(u, "://")
+	if !ok {
+		return "<webhook>"
+	}
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		rest = rest[i+1:]
+	}
+	return scheme + "://" + rest + "/…"
+}